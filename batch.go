@@ -0,0 +1,149 @@
+package robotstxt
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Decision is the result of checking a URL with IsAllowedBatch
+type Decision struct {
+	// Allowed is the same result IsAllowed returns for the URL
+	Allowed bool
+	// Err is the same error IsAllowed returns for the URL, if any
+	Err error
+}
+
+// IsAllowedBatch checks if each of the URLs is allowed for the user
+// agent, returning the same results as calling IsAllowed for each one.
+// The group for the user agent is only found once and URLs on the host
+// of the robots.txt file are matched without being fully parsed so
+// only the returned slice is allocated for most URLs.
+func (r *RobotsTxt) IsAllowedBatch(userAgent string, urls []string) []Decision {
+	decisions := make([]Decision, len(urls))
+	_, group := r.findGroup(userAgent)
+
+	for i, urlStr := range urls {
+		path, err := r.urlMatchPath(urlStr)
+		if err != nil {
+			decisions[i].Err = err
+			continue
+		}
+
+		decisions[i].Allowed = group == nil || group.isAllowed(path, r.precedence)
+	}
+
+	return decisions
+}
+
+// IsPathAllowed checks if the path and query of a URL on the host of
+// the robots.txt file, such as "/search?q=robots", is allowed. It is the
+// same as calling IsAllowed with the scheme and host of the robots.txt
+// file followed by pathAndQuery.
+func (r *RobotsTxt) IsPathAllowed(userAgent string, pathAndQuery string) (bool, error) {
+	path, ok := matchPathString(pathAndQuery)
+	if !ok {
+		var err error
+		path, err = r.urlMatchPath(r.url.Scheme + "://" + r.url.Host + pathAndQuery)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if _, group := r.findGroup(userAgent); group != nil {
+		return group.isAllowed(path, r.precedence), nil
+	}
+
+	return true, nil
+}
+
+// urlMatchPath returns the path and query of the URL as matchPath does
+// or an error if it is invalid or not on the host of the robots.txt file
+func (r *RobotsTxt) urlMatchPath(urlStr string) (string, error) {
+	if rest, ok := r.trimOrigin(urlStr); ok {
+		if path, ok := matchPathString(rest); ok {
+			return path, nil
+		}
+	}
+
+	u, err := parseAndNormalizeURL(urlStr)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme != r.url.Scheme || u.Host != r.url.Host {
+		return "", &InvalidHostError{}
+	}
+
+	return matchPath(u), nil
+}
+
+// trimOrigin returns what follows the scheme and host of the robots.txt
+// file in urlStr or false if urlStr does not start with them exactly
+func (r *RobotsTxt) trimOrigin(urlStr string) (string, bool) {
+	scheme, host := r.url.Scheme, r.url.Host
+
+	rest, ok := strings.CutPrefix(urlStr, scheme)
+	if ok {
+		rest, ok = strings.CutPrefix(rest, "://")
+	}
+	if ok {
+		rest, ok = strings.CutPrefix(rest, host)
+	}
+
+	return rest, ok
+}
+
+// matchPathString returns the same path matchPath would for a URL made
+// of the scheme and host of the robots.txt file followed by s. Nothing
+// is allocated unless the path is empty or needs unescaping. It returns
+// false if s must be parsed with the rest of the URL instead, such as
+// if it is invalid or does not start with a path, query or fragment.
+func matchPathString(s string) (string, bool) {
+	if s != "" && s[0] != '/' && s[0] != '?' && s[0] != '#' {
+		return "", false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < ' ' || c == 0x7F {
+			return "", false
+		}
+	}
+
+	s, fragment, _ := strings.Cut(s, "#")
+	if strings.IndexByte(fragment, '%') > -1 {
+		if _, err := url.PathUnescape(fragment); err != nil {
+			return "", false
+		}
+	}
+
+	path, query, hasQuery := strings.Cut(s, "?")
+	if strings.IndexByte(path, '%') < 0 && (!hasQuery || strings.IndexByte(query, '%') < 0) {
+		switch {
+		case path != "":
+			return s, true
+		case !hasQuery:
+			return "/", true
+		}
+	}
+
+	if strings.IndexByte(path, '%') > -1 {
+		var err error
+		if path, err = unescapePath(path); err != nil {
+			return "", false
+		}
+	}
+
+	if path == "" {
+		path = "/"
+	}
+
+	if !hasQuery {
+		return path, true
+	}
+
+	if unescapedQuery, err := unescapePath(query); err == nil {
+		query = unescapedQuery
+	}
+
+	return path + "?" + query, true
+}
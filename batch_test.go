@@ -0,0 +1,129 @@
+package robotstxt
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestRobotsTxt_isAllowedBatchSameAsIsAllowed(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	robots, _ := Parse(`
+		User-agent: *
+		Disallow: /a
+		Allow: /a?b
+		Disallow: /*%3F$
+		Disallow: /%E6%B5%8B
+		Disallow: /?
+		Allow: /b%2Fc
+	`, "http://www.example.com/robots.txt")
+
+	urls := []string{
+		"http://www.example.com",
+		"http://www.example.com?",
+		"http://www.example.com?a",
+		"http://www.example.com#a",
+		"http://www.example.com/a#%zz",
+		"http://www.example.com/%zz",
+		"http://www.example.com/a\x7f",
+		"http://www.example.com:80/a",
+		"http://www.example.comx/a",
+		"http://user@www.example.com/a",
+		"HTTP://www.example.com/a",
+		"https://www.example.com/a",
+		"http://www.例子.com/a",
+		"http://www.example.com/测",
+		"http://www.example.com/b/c",
+		"://",
+	}
+
+	for i := 0; i < 2000; i++ {
+		urls = append(urls, randomString(rnd, "/ab?#%2F3E6 \x01", "http://www.example.com", 10))
+	}
+
+	for _, userAgent := range []string{"*", "Bot"} {
+		decisions := robots.IsAllowedBatch(userAgent, urls)
+
+		for i, urlStr := range urls {
+			allowed, err := robots.IsAllowed(userAgent, urlStr)
+			if allowed != decisions[i].Allowed || !reflect.DeepEqual(err, decisions[i].Err) {
+				t.Errorf("Expected %q to be %v, %v got %v, %v", urlStr, allowed, err, decisions[i].Allowed, decisions[i].Err)
+			}
+
+			if rest, ok := robots.trimOrigin(urlStr); ok {
+				allowed, err := robots.IsPathAllowed(userAgent, rest)
+				if allowed != decisions[i].Allowed || !reflect.DeepEqual(err, decisions[i].Err) {
+					t.Errorf("Expected path %q to be %v, %v got %v, %v", rest, decisions[i].Allowed, decisions[i].Err, allowed, err)
+				}
+			}
+		}
+	}
+}
+
+func TestRobotsTxt_isPathAllowed(t *testing.T) {
+	robots, _ := Parse("User-agent: *\nDisallow: /private\n", "http://www.example.com/robots.txt")
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/private/a", false},
+		{"/public?private", true},
+		{"", true},
+		{"/%70rivate", false},
+	}
+
+	for _, test := range tests {
+		if allowed, err := robots.IsPathAllowed("bot", test.path); err != nil || allowed != test.allowed {
+			t.Errorf("Expected %q to be %v, got %v, %v", test.path, test.allowed, allowed, err)
+		}
+	}
+
+	if _, err := robots.IsPathAllowed("bot", ".evil.com/private"); err == nil {
+		t.Errorf("Expected an error for a path that changes the host")
+	}
+}
+
+func TestRobotsTxt_isAllowedBatchAllocations(t *testing.T) {
+	robots, _ := Parse(largeRobotsTxt(100), "http://www.example.com/robots.txt")
+	urls := []string{
+		"http://www.example.com/section-10/private/index.html",
+		"http://www.example.com/section-11/private/a?b=c",
+		"http://www.example.com/other.pdf#top",
+		"http://www.example.com",
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		robots.IsAllowedBatch("bot", urls)
+	})
+
+	if allocs != 1 {
+		t.Errorf("Expected only the decisions to be allocated, got %v allocations", allocs)
+	}
+}
+
+func BenchmarkIsAllowedBatch(b *testing.B) {
+	robots, _ := Parse(largeRobotsTxt(1000), "http://www.example.com/robots.txt")
+
+	urls := make([]string, 500)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("http://www.example.com/section-%d/private/page-%d.html?ref=%d", i, i, i)
+	}
+
+	b.Run("IsAllowed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, urlStr := range urls {
+				robots.IsAllowed("Mozilla/5.0 (compatible; Bot/1.0)", urlStr)
+			}
+		}
+	})
+
+	b.Run("IsAllowedBatch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			robots.IsAllowedBatch("Mozilla/5.0 (compatible; Bot/1.0)", urls)
+		}
+	})
+}
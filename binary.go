@@ -0,0 +1,349 @@
+package robotstxt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// binaryMagic starts every binary encoded RobotsTxt
+const binaryMagic = "RTXT"
+
+// BinaryVersion is the version of the binary format written by
+// MarshalBinary. It changes whenever the format does.
+const BinaryVersion = 1
+
+// ErrBinaryVersion is returned by UnmarshalBinary for data written in a
+// different version of the binary format. The original robots.txt file
+// should be parsed again instead.
+var ErrBinaryVersion = errors.New("robotstxt: unsupported binary format version")
+
+var errInvalidBinary = errors.New("robotstxt: invalid binary data")
+
+// MarshalBinary implements encoding.BinaryMarshaler. The encoding holds
+// the compiled rules, groups, sitemaps, host and URL of the robots.txt
+// file so it can be loaded without parsing it again. Directives,
+// diagnostics and fetch information are not included.
+func (r *RobotsTxt) MarshalBinary() ([]byte, error) {
+	e := &binaryEncoder{buf: append([]byte(binaryMagic), BinaryVersion)}
+
+	e.string(r.url.String())
+	e.uint(uint64(r.precedence))
+	e.uint(uint64(r.matching))
+	e.bool(r.truncated)
+	e.string(r.host)
+	e.strings(r.sitemaps)
+
+	fallbacks := make([]string, 0, len(r.fallbacks))
+	for userAgent := range r.fallbacks {
+		fallbacks = append(fallbacks, userAgent)
+	}
+	sort.Strings(fallbacks)
+
+	e.uint(uint64(len(fallbacks)))
+	for _, userAgent := range fallbacks {
+		e.string(userAgent)
+		e.strings(r.fallbacks[userAgent])
+	}
+
+	// Rules are shared between groups and records so are written once
+	// and referred to by index
+	indexes := make(map[*rule]uint64)
+	var rules []*rule
+	addRules := func(groupRules []*rule) {
+		for _, rule := range groupRules {
+			if _, ok := indexes[rule]; !ok {
+				indexes[rule] = uint64(len(rules))
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	userAgents := make([]string, 0, len(r.groups))
+	for userAgent, group := range r.groups {
+		userAgents = append(userAgents, userAgent)
+		addRules(group.rules)
+	}
+	sort.Strings(userAgents)
+
+	for _, record := range r.records {
+		addRules(record.rules)
+	}
+
+	e.uint(uint64(len(rules)))
+	for _, rule := range rules {
+		e.bool(rule.isAllowed)
+		e.bool(rule.pattern != nil)
+		e.string(rule.path)
+		e.string(rule.value)
+		e.string(rule.text)
+		e.uint(uint64(rule.line))
+	}
+
+	writeRules := func(groupRules []*rule) {
+		e.uint(uint64(len(groupRules)))
+		for _, rule := range groupRules {
+			e.uint(indexes[rule])
+		}
+	}
+
+	e.uint(uint64(len(userAgents)))
+	for _, userAgent := range userAgents {
+		group := r.groups[userAgent]
+		e.string(userAgent)
+		e.uint(uint64(group.line))
+		e.int(int64(group.crawlDelay))
+		writeRules(group.rules)
+	}
+
+	e.uint(uint64(len(r.records)))
+	for _, record := range r.records {
+		e.uint(uint64(len(record.userAgents)))
+		for _, ua := range record.userAgents {
+			e.string(ua.name)
+			e.uint(uint64(ua.line))
+		}
+		e.int(int64(record.crawlDelay))
+		e.bool(record.hasCrawlDelay)
+		writeRules(record.rules)
+	}
+
+	return e.buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It returns an
+// error wrapping ErrBinaryVersion if the data was written by a different
+// version of the format.
+func (r *RobotsTxt) UnmarshalBinary(data []byte) error {
+	if len(data) < len(binaryMagic)+1 || string(data[:len(binaryMagic)]) != binaryMagic {
+		return errInvalidBinary
+	}
+
+	if version := data[len(binaryMagic)]; version != BinaryVersion {
+		return fmt.Errorf("%w: got %d, want %d", ErrBinaryVersion, version, BinaryVersion)
+	}
+
+	// Strings are sliced from a single copy of the data
+	d := &binaryDecoder{data: string(data[len(binaryMagic)+1:])}
+
+	u, err := parseAndNormalizeURL(d.string())
+	if err != nil {
+		return err
+	}
+
+	loaded := RobotsTxt{
+		url:        u,
+		groups:     make(map[string]*group),
+		precedence: Precedence(d.uint()),
+		matching:   UserAgentMatching(d.uint()),
+		truncated:  d.bool(),
+		host:       d.string(),
+		sitemaps:   d.strings(),
+	}
+
+	if n := d.length(); n > 0 {
+		loaded.fallbacks = make(map[string][]string, n)
+		for i := 0; i < n; i++ {
+			userAgent := d.string()
+			loaded.fallbacks[userAgent] = d.strings()
+		}
+	}
+
+	// Rules are allocated together as there can be many thousands
+	stored := make([]rule, d.length())
+	rules := make([]*rule, len(stored))
+	for i := range rules {
+		rule := &stored[i]
+		rule.isAllowed = d.bool()
+
+		isPattern := d.bool()
+		rule.path = d.string()
+		rule.value = d.string()
+		rule.text = d.string()
+		rule.line = int(d.uint())
+
+		if isPattern {
+			rule.pattern = compileGlob(rule.path)
+		}
+
+		rules[i] = rule
+	}
+
+	readRules := func() []*rule {
+		n := d.length()
+		if n == 0 {
+			return nil
+		}
+
+		groupRules := make([]*rule, n)
+		for i := range groupRules {
+			index := d.uint()
+			if index >= uint64(len(rules)) {
+				d.fail()
+				return nil
+			}
+
+			groupRules[i] = rules[index]
+		}
+
+		return groupRules
+	}
+
+	for i, n := 0, d.length(); i < n; i++ {
+		userAgent := d.string()
+		loaded.groups[userAgent] = &group{
+			line:       int(d.uint()),
+			crawlDelay: time.Duration(d.int()),
+			rules:      readRules(),
+		}
+	}
+
+	loaded.records = make([]*record, d.length())
+	for i := range loaded.records {
+		record := &record{userAgents: make([]userAgentLine, d.length())}
+		for j := range record.userAgents {
+			record.userAgents[j] = userAgentLine{name: d.string(), line: int(d.uint())}
+		}
+		record.crawlDelay = time.Duration(d.int())
+		record.hasCrawlDelay = d.bool()
+		record.rules = readRules()
+
+		loaded.records[i] = record
+	}
+
+	if d.err != nil || d.data != "" {
+		return errInvalidBinary
+	}
+
+	*r = loaded
+
+	return nil
+}
+
+type binaryEncoder struct {
+	buf []byte
+}
+
+func (e *binaryEncoder) uint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *binaryEncoder) int(v int64) {
+	e.buf = binary.AppendVarint(e.buf, v)
+}
+
+func (e *binaryEncoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *binaryEncoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *binaryEncoder) strings(s []string) {
+	e.uint(uint64(len(s)))
+	for _, v := range s {
+		e.string(v)
+	}
+}
+
+// binaryDecoder reads values written by binaryEncoder. Once the data
+// is found to be invalid err is set and zero values are returned.
+type binaryDecoder struct {
+	data string
+	err  error
+}
+
+func (d *binaryDecoder) fail() {
+	d.err = errInvalidBinary
+	d.data = ""
+}
+
+func (d *binaryDecoder) uint() uint64 {
+	var v uint64
+	var s uint
+	for i := 0; i < len(d.data) && i < binary.MaxVarintLen64; i++ {
+		b := d.data[i]
+		if b < 0x80 {
+			d.data = d.data[i+1:]
+			return v | uint64(b)<<s
+		}
+
+		v |= uint64(b&0x7f) << s
+		s += 7
+	}
+
+	d.fail()
+
+	return 0
+}
+
+func (d *binaryDecoder) int() int64 {
+	u := d.uint()
+
+	// Undo the zig-zag encoding of binary.AppendVarint
+	v := int64(u >> 1)
+	if u&1 != 0 {
+		v = ^v
+	}
+
+	return v
+}
+
+func (d *binaryDecoder) bool() bool {
+	if d.data == "" {
+		d.fail()
+		return false
+	}
+
+	v := d.data[0] != 0
+	d.data = d.data[1:]
+
+	return v
+}
+
+// length reads a count of following values, failing if there can not
+// be that many left so corrupt data can not cause huge allocations
+func (d *binaryDecoder) length() int {
+	n := d.uint()
+	if n > uint64(len(d.data)) {
+		d.fail()
+		return 0
+	}
+
+	return int(n)
+}
+
+func (d *binaryDecoder) string() string {
+	n := d.uint()
+	if n > uint64(len(d.data)) {
+		d.fail()
+		return ""
+	}
+
+	s := d.data[:n]
+	d.data = d.data[n:]
+
+	return s
+}
+
+func (d *binaryDecoder) strings() []string {
+	n := d.length()
+	if n == 0 {
+		return nil
+	}
+
+	s := make([]string, n)
+	for i := range s {
+		s[i] = d.string()
+	}
+
+	return s
+}
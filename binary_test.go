@@ -0,0 +1,119 @@
+package robotstxt
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRobotsTxt_binaryRoundTrip(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := append([]string{largeRobotsTxt(100)}, roundTripContents...)
+
+	for _, c := range contents {
+		for _, opts := range [][]Option{nil, {WithPrecedence(LegacyOrder)}} {
+			original, _ := Parse(c, url, opts...)
+
+			data, err := original.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var loaded RobotsTxt
+			if err := loaded.UnmarshalBinary(data); err != nil {
+				t.Fatalf("Unexpected error %v for:\n%s", err, c)
+			}
+
+			assertSameDecisions(t, original, &loaded)
+
+			if !reflect.DeepEqual(original.Groups(), loaded.Groups()) {
+				t.Errorf("Expected groups %v, got %v", original.Groups(), loaded.Groups())
+			}
+
+			for _, path := range []string{"/", "/fish/", "/section-10/private/index.html", "/a.pdf"} {
+				expected, _ := original.Explain("*", "http://www.example.com"+path)
+				actual, _ := loaded.Explain("*", "http://www.example.com"+path)
+				if !reflect.DeepEqual(expected, actual) {
+					t.Errorf("Expected explanation %+v for %s, got %+v", expected, path, actual)
+				}
+			}
+		}
+	}
+}
+
+func TestRobotsTxt_binaryKeepsURL(t *testing.T) {
+	original, _ := Parse("User-agent: *\nDisallow: /\n", "https://www.example.com:8080/robots.txt")
+	data, _ := original.MarshalBinary()
+
+	var loaded RobotsTxt
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loaded.IsAllowed("*", "http://www.example.com/"); err == nil {
+		t.Errorf("Expected an error for a URL on another host")
+	}
+
+	if allowed, err := loaded.IsAllowed("*", "https://www.example.com:8080/a"); err != nil || allowed {
+		t.Errorf("Expected /a to be disallowed, got %v, %v", allowed, err)
+	}
+}
+
+func TestRobotsTxt_binaryVersionMismatch(t *testing.T) {
+	original, _ := Parse("User-agent: *\nDisallow: /\n", "http://www.example.com/robots.txt")
+	data, _ := original.MarshalBinary()
+	data[len(binaryMagic)] = BinaryVersion + 1
+
+	var loaded RobotsTxt
+	if err := loaded.UnmarshalBinary(data); !errors.Is(err, ErrBinaryVersion) {
+		t.Errorf("Expected ErrBinaryVersion, got %v", err)
+	}
+}
+
+func TestRobotsTxt_binaryInvalidData(t *testing.T) {
+	original, _ := Parse(largeRobotsTxt(20), "http://www.example.com/robots.txt")
+	data, _ := original.MarshalBinary()
+
+	for i := 0; i < len(data); i++ {
+		var loaded RobotsTxt
+		if err := loaded.UnmarshalBinary(data[:i]); err == nil {
+			t.Fatalf("Expected an error for data truncated to %d bytes", i)
+		}
+	}
+
+	var loaded RobotsTxt
+	if err := loaded.UnmarshalBinary(append(data, 0)); err == nil {
+		t.Errorf("Expected an error for trailing data")
+	}
+
+	if err := loaded.UnmarshalBinary([]byte("User-agent: *")); err == nil || errors.Is(err, ErrBinaryVersion) {
+		t.Errorf("Expected an invalid data error, got %v", err)
+	}
+}
+
+func BenchmarkUnmarshalBinary(b *testing.B) {
+	url := "http://www.example.com/robots.txt"
+
+	for name, contents := range map[string]string{
+		"Site":  string(siteRobotsTxt(1)),
+		"Large": largeRobotsTxt(10000),
+	} {
+		robots, _ := Parse(contents, url)
+		data, _ := robots.MarshalBinary()
+
+		b.Run(name+"/Parse", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Parse(contents, url)
+			}
+		})
+
+		b.Run(name+"/UnmarshalBinary", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var loaded RobotsTxt
+				loaded.UnmarshalBinary(data)
+			}
+		})
+	}
+}
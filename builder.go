@@ -0,0 +1,291 @@
+package robotstxt
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// BuilderError is returned by a Builder or Document when a value would
+// not be read back as intended
+type BuilderError struct {
+	// Directive is the directive the value was for, e.g. "disallow"
+	Directive string
+	// Value is the rejected value
+	Value string
+	// Reason describes why the value was rejected
+	Reason string
+}
+
+func (e *BuilderError) Error() string {
+	return "robotstxt: invalid " + e.Directive + " " + strconv.Quote(e.Value) + ": " + e.Reason
+}
+
+// Builder constructs a robots.txt file. Values are checked as they are
+// added and the first invalid one is reported by Text and Build, after
+// which all further calls are ignored.
+//
+//	b := robotstxt.NewBuilder()
+//	b.Group("Googlebot").Disallow("/private/").Allow("/private/ok").CrawlDelay(2)
+//	b.Group("*").Disallow("/")
+//	b.Sitemap("http://www.example.com/sitemap.xml")
+//	text, err := b.Text()
+type Builder struct {
+	groups   []*GroupBuilder
+	sitemaps []string
+	host     string
+	err      error
+}
+
+// GroupBuilder adds rules to a group of a Builder
+type GroupBuilder struct {
+	builder    *Builder
+	userAgents []string
+	lines      []string
+}
+
+// NewBuilder returns an empty Builder
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Group starts a new group for the user agents. User agents must be *
+// or an RFC 9309 product token, e.g. "Googlebot" not "Googlebot/2.1".
+func (b *Builder) Group(userAgents ...string) *GroupBuilder {
+	g := &GroupBuilder{builder: b}
+	if b.err != nil {
+		return g
+	}
+
+	if len(userAgents) == 0 {
+		b.fail("user-agent", "", "a group needs at least one user agent")
+		return g
+	}
+
+	for _, userAgent := range userAgents {
+		if reason := checkUserAgent(userAgent); reason != "" {
+			b.fail("user-agent", userAgent, reason)
+			return g
+		}
+	}
+
+	g.userAgents = userAgents
+	b.groups = append(b.groups, g)
+
+	return g
+}
+
+// Sitemap adds a sitemap. The URL must be an absolute http or https URL.
+func (b *Builder) Sitemap(sitemapURL string) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	if reason := checkSitemap(sitemapURL); reason != "" {
+		b.fail("sitemap", sitemapURL, reason)
+		return b
+	}
+
+	b.sitemaps = append(b.sitemaps, sitemapURL)
+
+	return b
+}
+
+// Host sets the preferred host
+func (b *Builder) Host(host string) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	if host == "" || hasUnsafeChars(host) {
+		b.fail("host", host, "must not be empty or contain whitespace, control characters or #")
+		return b
+	}
+
+	b.host = host
+
+	return b
+}
+
+// Err returns the first invalid value added, if any
+func (b *Builder) Err() error {
+	return b.err
+}
+
+// Text returns the robots.txt file. Groups, rules and sitemaps are
+// written in the order they were added.
+func (b *Builder) Text() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+
+	var sb strings.Builder
+	for i, g := range b.groups {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+
+		for _, userAgent := range g.userAgents {
+			sb.WriteString("User-agent: " + userAgent + "\n")
+		}
+
+		// Groups with no rules still stop the * group applying
+		if len(g.lines) == 0 {
+			sb.WriteString("Disallow:\n")
+		}
+
+		for _, line := range g.lines {
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	if len(b.sitemaps) > 0 || b.host != "" {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+
+		for _, sitemap := range b.sitemaps {
+			sb.WriteString("Sitemap: " + sitemap + "\n")
+		}
+
+		if b.host != "" {
+			sb.WriteString("Host: " + b.host + "\n")
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// Build parses the robots.txt file for the robots.txt URL urlStr
+func (b *Builder) Build(urlStr string, opts ...Option) (*RobotsTxt, error) {
+	text, err := b.Text()
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(text, urlStr, opts...)
+}
+
+func (b *Builder) fail(directive, value, reason string) {
+	b.err = &BuilderError{Directive: directive, Value: value, Reason: reason}
+}
+
+// Allow adds an allow rule. See Disallow for valid paths.
+func (g *GroupBuilder) Allow(path string) *GroupBuilder {
+	return g.addRule("allow", path)
+}
+
+// Disallow adds a disallow rule. Paths must start with / or * and may
+// use * to match any characters and a trailing $ to match the end of
+// the URL. A literal $ at the end of a path must be written as %24.
+func (g *GroupBuilder) Disallow(path string) *GroupBuilder {
+	return g.addRule("disallow", path)
+}
+
+// CrawlDelay sets the crawl delay of the group in seconds
+func (g *GroupBuilder) CrawlDelay(seconds float64) *GroupBuilder {
+	b := g.builder
+	if b.err != nil {
+		return g
+	}
+
+	value := strconv.FormatFloat(seconds, 'f', -1, 64)
+	if seconds < 0 || math.IsInf(seconds, 0) || math.IsNaN(seconds) {
+		b.fail("crawl-delay", value, "must be a positive number of seconds")
+		return g
+	}
+
+	g.lines = append(g.lines, "Crawl-delay: "+value)
+
+	return g
+}
+
+// Group starts a new group, see Builder.Group
+func (g *GroupBuilder) Group(userAgents ...string) *GroupBuilder {
+	return g.builder.Group(userAgents...)
+}
+
+// Sitemap adds a sitemap, see Builder.Sitemap
+func (g *GroupBuilder) Sitemap(sitemapURL string) *Builder {
+	return g.builder.Sitemap(sitemapURL)
+}
+
+func (g *GroupBuilder) addRule(directive, path string) *GroupBuilder {
+	b := g.builder
+	if b.err != nil {
+		return g
+	}
+
+	if reason := checkRulePath(path); reason != "" {
+		b.fail(directive, path, reason)
+		return g
+	}
+
+	if directive == "allow" {
+		g.lines = append(g.lines, "Allow: "+path)
+	} else {
+		g.lines = append(g.lines, "Disallow: "+path)
+	}
+
+	return g
+}
+
+// checkUserAgent returns why the user agent is invalid or an empty
+// string if it is valid
+func checkUserAgent(userAgent string) string {
+	if userAgent != "*" && !isProductToken(userAgent) {
+		return "must be * or only contain the characters a-z, A-Z, _ and -"
+	}
+
+	return ""
+}
+
+// checkSitemap returns why the sitemap URL is invalid or an empty
+// string if it is valid
+func checkSitemap(sitemapURL string) string {
+	u, err := url.Parse(sitemapURL)
+	switch {
+	case err != nil:
+		return "not a valid URL"
+	case u.Scheme != "http" && u.Scheme != "https", u.Host == "":
+		return "must be an absolute http or https URL"
+	case hasUnsafeChars(sitemapURL):
+		return "must not contain whitespace, control characters or #"
+	}
+
+	return ""
+}
+
+// checkRulePath returns why the path would not be read as written
+// or an empty string if it is valid
+func checkRulePath(path string) string {
+	switch {
+	case path == "":
+		return "empty rules match nothing, use a group with no rules instead"
+	case path[0] != '/' && path[0] != '*':
+		return "must start with / or *"
+	case hasUnsafeChars(path):
+		return "must not contain whitespace, control characters or #"
+	case strings.Contains(path[:len(path)-1], "$"):
+		return "$ only matches the end of the URL at the end of a path, use %24 for a literal $"
+	}
+
+	if _, err := url.PathUnescape(path); err != nil {
+		return "contains an invalid percent encoding, use %25 for a literal %"
+	}
+
+	return ""
+}
+
+// hasUnsafeChars checks if the value would be changed or cut short
+// when written to a line of a robots.txt file
+func hasUnsafeChars(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c <= ' ' || c == 0x7F || c == '#' {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,128 @@
+package robotstxt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBuilder_text(t *testing.T) {
+	b := NewBuilder()
+	b.Group("Googlebot").Disallow("/private/").Allow("/private/ok").CrawlDelay(2.5).
+		Group("a", "b").
+		Group("*").Disallow("/*.pdf$").Disallow("/price%24").
+		Sitemap("http://www.example.com/sitemap.xml").
+		Host("www.example.com")
+
+	expected := "User-agent: Googlebot\n" +
+		"Disallow: /private/\n" +
+		"Allow: /private/ok\n" +
+		"Crawl-delay: 2.5\n" +
+		"\n" +
+		"User-agent: a\n" +
+		"User-agent: b\n" +
+		"Disallow:\n" +
+		"\n" +
+		"User-agent: *\n" +
+		"Disallow: /*.pdf$\n" +
+		"Disallow: /price%24\n" +
+		"\n" +
+		"Sitemap: http://www.example.com/sitemap.xml\n" +
+		"Host: www.example.com\n"
+
+	text, err := b.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, text)
+	}
+}
+
+func TestBuilder_build(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+
+	b := NewBuilder()
+	b.Group("Googlebot").Disallow("/private/").Allow("/private/ok").CrawlDelay(2)
+	b.Group("*").Disallow("/")
+
+	robots, err := b.Build(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allowed, _ := robots.IsAllowed("Googlebot", "http://www.example.com/private/ok")
+	if !allowed {
+		t.Errorf("Expected /private/ok to be allowed")
+	}
+
+	allowed, _ = robots.IsAllowed("Googlebot", "http://www.example.com/private/no")
+	if allowed {
+		t.Errorf("Expected /private/no to be disallowed")
+	}
+
+	allowed, _ = robots.IsAllowed("other", "http://www.example.com/")
+	if allowed {
+		t.Errorf("Expected / to be disallowed for other")
+	}
+
+	if delay := robots.CrawlDelay("Googlebot"); delay != 2*time.Second {
+		t.Errorf("Expected crawl delay 2s, got %v", delay)
+	}
+
+	if len(robots.Diagnostics()) != 0 {
+		t.Errorf("Expected no diagnostics, got %v", robots.Diagnostics())
+	}
+}
+
+func TestBuilder_rejectInvalidValues(t *testing.T) {
+	tests := []struct {
+		build     func(b *Builder)
+		directive string
+		value     string
+	}{
+		{func(b *Builder) { b.Group() }, "user-agent", ""},
+		{func(b *Builder) { b.Group("Googlebot/2.1") }, "user-agent", "Googlebot/2.1"},
+		{func(b *Builder) { b.Group("my bot") }, "user-agent", "my bot"},
+		{func(b *Builder) { b.Group("*").Disallow("") }, "disallow", ""},
+		{func(b *Builder) { b.Group("*").Disallow("private") }, "disallow", "private"},
+		{func(b *Builder) { b.Group("*").Allow("/a#b") }, "allow", "/a#b"},
+		{func(b *Builder) { b.Group("*").Allow("/a b") }, "allow", "/a b"},
+		{func(b *Builder) { b.Group("*").Allow("/a\nDisallow: /") }, "allow", "/a\nDisallow: /"},
+		{func(b *Builder) { b.Group("*").Disallow("/price$/") }, "disallow", "/price$/"},
+		{func(b *Builder) { b.Group("*").Disallow("/%B/") }, "disallow", "/%B/"},
+		{func(b *Builder) { b.Group("*").CrawlDelay(-1) }, "crawl-delay", "-1"},
+		{func(b *Builder) { b.Sitemap("/sitemap.xml") }, "sitemap", "/sitemap.xml"},
+		{func(b *Builder) { b.Sitemap("ftp://example.com/sitemap.xml") }, "sitemap", "ftp://example.com/sitemap.xml"},
+		{func(b *Builder) { b.Host("example.com # comment") }, "host", "example.com # comment"},
+	}
+
+	for _, test := range tests {
+		b := NewBuilder()
+		test.build(b)
+
+		var builderErr *BuilderError
+		if _, err := b.Text(); !errors.As(err, &builderErr) {
+			t.Errorf("Expected a BuilderError for %q, got %v", test.value, err)
+			continue
+		}
+
+		if builderErr.Directive != test.directive || builderErr.Value != test.value {
+			t.Errorf("Expected error for %s %q, got %v", test.directive, test.value, builderErr)
+		}
+	}
+}
+
+func TestBuilder_keepFirstError(t *testing.T) {
+	b := NewBuilder()
+	b.Group("*").Disallow("relative").Allow("/ok").Sitemap("invalid")
+
+	if _, err := b.Build("http://www.example.com/robots.txt"); err == nil || err != b.Err() {
+		t.Fatalf("Expected the builder error, got %v", err)
+	}
+
+	if err := b.Err().(*BuilderError); err.Value != "relative" {
+		t.Errorf("Expected the first error to be kept, got %v", err)
+	}
+}
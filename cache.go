@@ -0,0 +1,391 @@
+package robotstxt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxCacheTTL is the longest a robots.txt file will be cached for.
+// RFC 9309 says crawlers should not use a cached copy for more than
+// 24 hours unless the robots.txt file is unreachable.
+const MaxCacheTTL = 24 * time.Hour
+
+// DefaultMinTTL is the shortest a robots.txt file will be cached for
+// when its response headers say not to cache it
+const DefaultMinTTL = time.Minute
+
+// DefaultFetchTimeout is how long Cache waits for a robots.txt file
+// before treating it as unreachable
+const DefaultFetchTimeout = 30 * time.Second
+
+// DefaultErrorTTL is how long to wait before retrying a robots.txt file
+// that could not be fetched
+const DefaultErrorTTL = 5 * time.Minute
+
+// Cache fetches robots.txt files on demand and caches them per origin.
+// It is safe for concurrent use and concurrent requests for the same
+// origin share a single fetch.
+//
+// The zero value is ready to use.
+type Cache struct {
+	// Client is used to fetch robots.txt files. If nil,
+	// http.DefaultClient is used. Fetches are limited by FetchTimeout.
+	Client *http.Client
+
+	// TTL is how long to cache robots.txt files for if the response
+	// has no Cache-Control or Expires header. Defaults to MaxCacheTTL.
+	// TTLs from headers are capped at MaxCacheTTL.
+	TTL time.Duration
+
+	// MinTTL is the shortest time to cache robots.txt files for, even
+	// if the response has a Cache-Control or Expires header that says
+	// not to cache it, so every URL checked does not mean a fetch.
+	// Defaults to DefaultMinTTL.
+	MinTTL time.Duration
+
+	// ErrorTTL is how long to wait before retrying a robots.txt file
+	// that was unreachable. Defaults to DefaultErrorTTL.
+	ErrorTTL time.Duration
+
+	// FetchTimeout is how long to wait for a robots.txt file before
+	// treating it as unreachable. Fetches are shared by every caller
+	// waiting for the origin so do not end when a caller's context
+	// does. Defaults to DefaultFetchTimeout.
+	FetchTimeout time.Duration
+
+	// Options are used when parsing robots.txt files
+	Options []Option
+
+	// Store, if set, persists fetched robots.txt files so they survive
+	// restarts and can be shared between processes. Entries in the store
+	// are used instead of fetching until they expire.
+	Store Store
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	now     func() time.Time
+}
+
+// cacheSweepSize is how many entries each lookup checks for expiry
+const cacheSweepSize = 8
+
+type cacheEntry struct {
+	robots  *RobotsTxt
+	err     error
+	expires time.Time
+	// done is non-nil while a fetch is in progress and
+	// is closed when it completes
+	done chan struct{}
+}
+
+// Get returns the robots.txt file for the origin of urlStr, fetching
+// it if it is not cached or has expired.
+//
+// If the file becomes unreachable after it has been fetched successfully,
+// the last good copy is returned until it can be fetched again.
+func (c *Cache) Get(ctx context.Context, urlStr string) (*RobotsTxt, error) {
+	robotsURL, err := RobotsURL(urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]*cacheEntry)
+	}
+
+	c.sweep()
+
+	e, ok := c.entries[robotsURL]
+	if !ok {
+		e = &cacheEntry{}
+		c.entries[robotsURL] = e
+	}
+
+	if e.done == nil && (e.robots != nil || e.err != nil) && c.timeNow().Before(e.expires) {
+		robots, err := e.result()
+		c.mu.Unlock()
+		return robots, err
+	}
+
+	if e.done == nil {
+		e.done = make(chan struct{})
+
+		// The fetch is not tied to ctx as other callers may be
+		// waiting for it
+		go c.refresh(robotsURL, e)
+	}
+
+	done := e.done
+	c.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return e.result()
+}
+
+// IsAllowed checks if urlStr is allowed by the robots.txt file for its
+// origin, fetching the robots.txt file if needed
+func (c *Cache) IsAllowed(ctx context.Context, userAgent string, urlStr string) (bool, error) {
+	robots, err := c.Get(ctx, urlStr)
+	if err != nil {
+		return false, err
+	}
+
+	return robots.IsAllowed(userAgent, urlStr)
+}
+
+// sweep removes entries that have expired and are not being fetched so
+// the cache does not grow without limit. Only a few entries are checked
+// each time, starting from a random one as map iteration order is
+// random, so lookups stay fast however many origins are cached. Good
+// copies are kept for MaxCacheTTL after they expire so they can still
+// be used if the robots.txt file becomes unreachable. c.mu must be held.
+func (c *Cache) sweep() {
+	now := c.timeNow()
+	checked := 0
+
+	for robotsURL, e := range c.entries {
+		if checked++; checked > cacheSweepSize {
+			break
+		}
+
+		if e.done != nil || now.Before(e.expires) {
+			continue
+		}
+
+		if e.robots == nil || now.Sub(e.expires) > MaxCacheTTL {
+			delete(c.entries, robotsURL)
+		}
+	}
+}
+
+// result returns the last good robots.txt file if there is one,
+// otherwise the last error
+func (e *cacheEntry) result() (*RobotsTxt, error) {
+	if e.robots != nil {
+		return e.robots, nil
+	}
+
+	return nil, e.err
+}
+
+func (c *Cache) refresh(robotsURL string, e *cacheEntry) {
+	ctx := context.Background()
+
+	c.mu.Lock()
+	hasRobots := e.robots != nil
+	c.mu.Unlock()
+
+	if !hasRobots && c.Store != nil {
+		if c.loadStored(ctx, robotsURL, e) {
+			return
+		}
+	}
+
+	var o options
+	for _, opt := range c.Options {
+		opt(&o)
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, c.fetchTimeout())
+	entry, err := fetchEntry(fetchCtx, c.Client, robotsURL, o.maxBytes)
+	cancel()
+
+	// A fetch that timed out is the same as an unreachable server
+	if entry == nil && errors.Is(err, context.DeadlineExceeded) {
+		entry = &Entry{URL: robotsURL, FinalURL: robotsURL, FetchedAt: time.Now()}
+	}
+
+	var robots *RobotsTxt
+	if entry != nil {
+		robots, err = c.parseEntry(entry, err)
+	}
+
+	if robots != nil && robots.FetchInfo().Status != StatusUnreachable && c.Store != nil {
+		// The store is only an optimisation so failing to
+		// update it is not an error
+		c.Store.Put(ctx, robotsURL, entry)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.timeNow()
+
+	switch {
+	case robots == nil:
+		e.err = err
+		e.expires = now.Add(c.errorTTL())
+	case robots.FetchInfo().Status == StatusUnreachable:
+		// Keep serving the last good copy if there is one
+		if e.robots == nil || e.robots.FetchInfo().Status == StatusUnreachable {
+			e.robots = robots
+		}
+		e.err = nil
+		e.expires = now.Add(c.errorTTL())
+	default:
+		e.robots = robots
+		e.err = nil
+		e.expires = now.Add(c.ttl(robots.FetchInfo().Header, now))
+	}
+
+	close(e.done)
+	e.done = nil
+}
+
+// loadStored loads the entry for robotsURL from the store. It returns
+// true if the stored entry has not expired and has been used, otherwise
+// the stored entry is kept as the last good copy.
+func (c *Cache) loadStored(ctx context.Context, robotsURL string, e *cacheEntry) bool {
+	stored, err := c.Store.Get(ctx, robotsURL)
+	if err != nil {
+		return false
+	}
+
+	robots, err := c.parseEntry(stored, nil)
+	if err != nil {
+		return false
+	}
+
+	expires := stored.FetchedAt.Add(c.ttl(stored.Header, stored.FetchedAt))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e.robots = robots
+	if !c.timeNow().Before(expires) {
+		return false
+	}
+
+	e.err = nil
+	e.expires = expires
+	close(e.done)
+	e.done = nil
+
+	return true
+}
+
+func (c *Cache) parseEntry(entry *Entry, fetchErr error) (*RobotsTxt, error) {
+	robots, err := entry.Parse(c.Options...)
+	if err != nil {
+		return nil, err
+	}
+
+	robots.fetch.Err = fetchErr
+
+	return robots, nil
+}
+
+func (c *Cache) timeNow() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+
+	return time.Now()
+}
+
+func (c *Cache) fetchTimeout() time.Duration {
+	if c.FetchTimeout > 0 {
+		return c.FetchTimeout
+	}
+
+	return DefaultFetchTimeout
+}
+
+func (c *Cache) errorTTL() time.Duration {
+	if c.ErrorTTL > 0 {
+		return c.ErrorTTL
+	}
+
+	return DefaultErrorTTL
+}
+
+func (c *Cache) ttl(header http.Header, now time.Time) time.Duration {
+	ttl, ok := ttlFromHeader(header, now)
+	if !ok {
+		ttl = c.TTL
+		if ttl <= 0 {
+			ttl = MaxCacheTTL
+		}
+	}
+
+	if ttl > MaxCacheTTL {
+		ttl = MaxCacheTTL
+	}
+
+	if minTTL := c.minTTL(); ttl < minTTL {
+		ttl = minTTL
+	}
+
+	return ttl
+}
+
+func (c *Cache) minTTL() time.Duration {
+	if c.MinTTL > 0 {
+		return c.MinTTL
+	}
+
+	return DefaultMinTTL
+}
+
+// ttlFromHeader returns how long a response can be cached for based on
+// its Cache-Control and Expires headers
+func ttlFromHeader(header http.Header, now time.Time) (time.Duration, bool) {
+	if header == nil {
+		return 0, false
+	}
+
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+
+		if directive == "no-store" || directive == "no-cache" {
+			return 0, true
+		}
+
+		if strings.HasPrefix(directive, "max-age=") {
+			seconds, err := strconv.ParseInt(strings.Trim(directive[len("max-age="):], `"`), 10, 64)
+			if err != nil || seconds < 0 {
+				return 0, true
+			}
+
+			if seconds > int64(MaxCacheTTL/time.Second) {
+				return MaxCacheTTL, true
+			}
+
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	if expiresStr := header.Get("Expires"); expiresStr != "" {
+		expires, err := http.ParseTime(expiresStr)
+		if err != nil {
+			// Invalid dates mean already expired
+			return 0, true
+		}
+
+		if date, err := http.ParseTime(header.Get("Date")); err == nil {
+			now = date
+		}
+
+		if ttl := expires.Sub(now); ttl > 0 {
+			return ttl, true
+		}
+
+		return 0, true
+	}
+
+	return 0, false
+}
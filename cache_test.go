@@ -0,0 +1,287 @@
+package robotstxt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestCache_fetchOncePerOrigin(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private")
+	}))
+	defer server.Close()
+
+	cache := &Cache{Client: server.Client()}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			allowed, err := cache.IsAllowed(context.Background(), "bot", fmt.Sprintf("%s/private/%d", server.URL, i))
+			if err != nil {
+				t.Error(err)
+			} else if allowed {
+				t.Errorf("The path /private/%d should be disallowed", i)
+			}
+		}(i)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	allowed, _ := cache.IsAllowed(context.Background(), "bot", server.URL+"/public")
+	if !allowed {
+		t.Errorf("The path /public should be allowed")
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected 1 request, got %d", n)
+	}
+}
+
+func TestCache_refreshAfterTTL(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private")
+	}))
+	defer server.Close()
+
+	clock := &testClock{now: time.Now()}
+	cache := &Cache{Client: server.Client(), now: clock.Now}
+
+	cache.Get(context.Background(), server.URL)
+	clock.Add(59 * time.Second)
+	cache.Get(context.Background(), server.URL)
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected 1 request before the TTL, got %d", n)
+	}
+
+	clock.Add(2 * time.Second)
+	cache.Get(context.Background(), server.URL)
+
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("Expected 2 requests after the TTL, got %d", n)
+	}
+}
+
+func TestCache_serveLastGoodCopyOnServerErrors(t *testing.T) {
+	var failing int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private")
+	}))
+	defer server.Close()
+
+	clock := &testClock{now: time.Now()}
+	cache := &Cache{Client: server.Client(), now: clock.Now}
+
+	allowed, _ := cache.IsAllowed(context.Background(), "bot", server.URL+"/public")
+	if !allowed {
+		t.Errorf("The path /public should be allowed")
+	}
+
+	atomic.StoreInt32(&failing, 1)
+	clock.Add(MaxCacheTTL + time.Second)
+
+	robots, _ := cache.Get(context.Background(), server.URL)
+	if robots.FetchInfo().Status != StatusParsed {
+		t.Errorf("Expected the last good copy, got %v", robots.FetchInfo().Status)
+	}
+
+	allowed, _ = cache.IsAllowed(context.Background(), "bot", server.URL+"/public")
+	if !allowed {
+		t.Errorf("The path /public should still be allowed")
+	}
+
+	uncached := &Cache{Client: server.Client()}
+	allowed, _ = uncached.IsAllowed(context.Background(), "bot", server.URL+"/public")
+	if allowed {
+		t.Errorf("The path /public should be disallowed without a good copy")
+	}
+}
+
+func TestCache_returnErrorWhenContextIsDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	cache := &Cache{Client: server.Client()}
+	if _, err := cache.Get(ctx, server.URL); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestCache_ttlFromHeader(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header http.Header
+		ttl    time.Duration
+		ok     bool
+	}{
+		{http.Header{}, 0, false},
+		{http.Header{"Cache-Control": {"max-age=3600"}}, time.Hour, true},
+		{http.Header{"Cache-Control": {"public, MAX-AGE=60"}}, time.Minute, true},
+		{http.Header{"Cache-Control": {"max-age=999999"}}, MaxCacheTTL, true},
+		{http.Header{"Cache-Control": {"no-cache"}}, 0, true},
+		{http.Header{"Cache-Control": {"max-age=invalid"}}, 0, true},
+		{http.Header{"Expires": {"Wed, 01 Jan 2020 02:00:00 GMT"}}, 2 * time.Hour, true},
+		{http.Header{
+			"Expires": {"Wed, 01 Jan 2020 02:00:00 GMT"},
+			"Date":    {"Wed, 01 Jan 2020 01:00:00 GMT"},
+		}, time.Hour, true},
+		{http.Header{"Expires": {"0"}}, 0, true},
+		{http.Header{
+			"Cache-Control": {"max-age=60"},
+			"Expires":       {"Wed, 01 Jan 2020 02:00:00 GMT"},
+		}, time.Minute, true},
+	}
+
+	for _, test := range tests {
+		ttl, ok := ttlFromHeader(test.header, now)
+		if ttl != test.ttl || ok != test.ok {
+			t.Errorf("Expected %v, %v for %v, got %v, %v", test.ttl, test.ok, test.header, ttl, ok)
+		}
+	}
+}
+
+func TestCache_minTTL(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "no-cache")
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private")
+	}))
+	defer server.Close()
+
+	clock := &testClock{now: time.Now()}
+	cache := &Cache{Client: server.Client(), now: clock.Now}
+
+	for i := 0; i < 10; i++ {
+		cache.IsAllowed(context.Background(), "bot", fmt.Sprintf("%s/page/%d", server.URL, i))
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected 1 request within the minimum TTL, got %d", n)
+	}
+
+	clock.Add(DefaultMinTTL + time.Second)
+	cache.Get(context.Background(), server.URL)
+
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("Expected 2 requests after the minimum TTL, got %d", n)
+	}
+}
+
+func TestCache_removeExpiredEntries(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private")
+	})
+
+	a := httptest.NewServer(handler)
+	defer a.Close()
+	b := httptest.NewServer(handler)
+	defer b.Close()
+
+	clock := &testClock{now: time.Now()}
+	cache := &Cache{Client: a.Client(), now: clock.Now}
+
+	cache.Get(context.Background(), a.URL)
+
+	// Kept while it can still be used as the last good copy
+	clock.Add(MaxCacheTTL + time.Hour)
+	cache.Get(context.Background(), b.URL)
+
+	cache.mu.Lock()
+	n := len(cache.entries)
+	cache.mu.Unlock()
+
+	if n != 2 {
+		t.Errorf("Expected 2 entries, got %d", n)
+	}
+
+	clock.Add(MaxCacheTTL)
+	cache.Get(context.Background(), b.URL)
+
+	cache.mu.Lock()
+	_, ok := cache.entries[a.URL+"/robots.txt"]
+	n = len(cache.entries)
+	cache.mu.Unlock()
+
+	if ok || n != 1 {
+		t.Errorf("Expected the expired entry to be removed, got %d entries", n)
+	}
+}
+
+func TestCache_treatFetchTimeoutsAsUnreachable(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	clock := &testClock{now: time.Now()}
+	cache := &Cache{Client: server.Client(), FetchTimeout: 50 * time.Millisecond, now: clock.Now}
+
+	allowed, err := cache.IsAllowed(context.Background(), "bot", server.URL+"/page")
+	if err != nil || allowed {
+		t.Errorf("Expected /page to be disallowed without an error, got %v, %v", allowed, err)
+	}
+
+	robots, _ := cache.Get(context.Background(), server.URL)
+	if info := robots.FetchInfo(); info.Status != StatusUnreachable || info.Err == nil {
+		t.Errorf("Expected robots.txt to be unreachable with an error, got %v", info)
+	}
+
+	clock.Add(DefaultErrorTTL + time.Second)
+	cache.Get(context.Background(), server.URL)
+
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("Expected the fetch to be retried after the error TTL, got %d requests", n)
+	}
+}
@@ -0,0 +1,226 @@
+package robotstxt
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// Compact is a compact, immutable form of a RobotsTxt for keeping large
+// numbers of robots.txt files in memory. It makes the same decisions as
+// the RobotsTxt it was made from but only keeps what is needed to make
+// them, so has no directives, diagnostics or line numbers.
+//
+// The rule paths of a file are packed into a single string. User agents
+// and compiled wildcard rules are shared between Compact values as the
+// same ones are used by many sites.
+//
+// Compact is safe for concurrent use.
+type Compact struct {
+	url        *url.URL
+	userAgents []string
+	groups     []compactGroup
+	sitemaps   []string
+	host       string
+	precedence Precedence
+	matching   UserAgentMatching
+	fallbacks  map[string][]string
+}
+
+// compactGroup is the group of the user agent with the same index in
+// Compact.userAgents
+type compactGroup struct {
+	paths      string
+	rules      []compactRule
+	crawlDelay time.Duration
+	index      *ruleIndex
+}
+
+// compactRule is a rule whose path is paths[start:end] of its group
+type compactRule struct {
+	start, end uint32
+	isAllowed  bool
+	pattern    *glob
+}
+
+// ParseBytes parses the contents of a robots.txt file into a Compact.
+// Lines are read from contents in place rather than copied to strings
+// and contents is not used once ParseBytes returns.
+func ParseBytes(contents []byte, urlStr string, opts ...Option) (*Compact, error) {
+	p, err := newParser(urlStr, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Safe as nothing parsed from contents is kept once it has been
+	// copied into the Compact
+	p.parseString(unsafe.String(unsafe.SliceData(contents), len(contents)), p.options.maxBytes)
+
+	return p.robotsTxt.Compact(), nil
+}
+
+// Compact returns a compact, immutable copy of the robots.txt file
+func (r *RobotsTxt) Compact() *Compact {
+	c := &Compact{
+		url:        r.url,
+		userAgents: make([]string, 0, len(r.groups)),
+		groups:     make([]compactGroup, len(r.groups)),
+		host:       strings.Clone(r.host),
+		precedence: r.precedence,
+		matching:   r.matching,
+		fallbacks:  r.fallbacks,
+	}
+
+	for _, sitemap := range r.sitemaps {
+		c.sitemaps = append(c.sitemaps, strings.Clone(sitemap))
+	}
+
+	for userAgent := range r.groups {
+		c.userAgents = append(c.userAgents, userAgent)
+	}
+	sort.Strings(c.userAgents)
+
+	// Rules are often in more than one group so each path is only
+	// packed once
+	var paths strings.Builder
+	offsets := make(map[string]uint32)
+	count := 0
+
+	for _, userAgent := range c.userAgents {
+		for _, rule := range r.groups[userAgent].rules {
+			if _, ok := offsets[rule.path]; !ok {
+				offsets[rule.path] = uint32(paths.Len())
+				paths.WriteString(rule.path)
+			}
+		}
+
+		count += len(r.groups[userAgent].rules)
+	}
+
+	packed := paths.String()
+	rules := make([]compactRule, 0, count)
+
+	for i, userAgent := range c.userAgents {
+		g := r.groups[userAgent]
+		first := len(rules)
+
+		for _, rule := range g.rules {
+			start := offsets[rule.path]
+			compacted := compactRule{
+				start:     start,
+				end:       start + uint32(len(rule.path)),
+				isAllowed: rule.isAllowed,
+			}
+
+			if rule.pattern != nil {
+				compacted.pattern = interned.glob(rule.path)
+			}
+
+			rules = append(rules, compacted)
+		}
+
+		c.userAgents[i] = interned.string(userAgent)
+		c.groups[i] = compactGroup{
+			paths:      packed,
+			rules:      rules[first:len(rules):len(rules)],
+			crawlDelay: g.crawlDelay,
+		}
+
+		if plain := len(g.rules) - countPatterns(g.rules); plain >= minIndexedRules {
+			c.groups[i].index = newRuleIndex(&c.groups[i])
+		}
+	}
+
+	return c
+}
+
+func countPatterns(rules []*rule) int {
+	count := 0
+	for _, rule := range rules {
+		if rule.pattern != nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+// findGroup returns the group that applies to the user agent or nil if
+// there is none
+func (c *Compact) findGroup(userAgent string) *compactGroup {
+	name, ok := findUserAgent(userAgent, c.matching, c.fallbacks, func(name string) bool {
+		i := sort.SearchStrings(c.userAgents, name)
+		return i < len(c.userAgents) && c.userAgents[i] == name
+	})
+
+	if !ok {
+		return nil
+	}
+
+	return &c.groups[sort.SearchStrings(c.userAgents, name)]
+}
+
+// IsAllowed checks if the specified URL is allowed by the robots.txt
+// file. See RobotsTxt.IsAllowed.
+func (c *Compact) IsAllowed(userAgent string, urlStr string) (bool, error) {
+	u, err := parseAndNormalizeURL(urlStr)
+	if err != nil {
+		return false, err
+	}
+
+	if u.Scheme != c.url.Scheme || u.Host != c.url.Host {
+		return false, &InvalidHostError{}
+	}
+
+	if g := c.findGroup(userAgent); g != nil {
+		return g.isAllowed(matchPath(u), c.precedence), nil
+	}
+
+	return true, nil
+}
+
+// CrawlDelay returns the crawl delay for the specified
+// user agent or 0 if there is none
+func (c *Compact) CrawlDelay(userAgent string) time.Duration {
+	if g := c.findGroup(userAgent); g != nil {
+		return g.crawlDelay
+	}
+
+	return 0
+}
+
+// Sitemaps returns a list of sitemaps from the robots.txt file if any
+func (c *Compact) Sitemaps() []string {
+	return c.sitemaps
+}
+
+// Host is the preferred hosts from the robots.txt file if there is one
+func (c *Compact) Host() string {
+	return c.host
+}
+
+func (g *compactGroup) isAllowed(path string, precedence Precedence) bool {
+	var i int
+	if g.index != nil {
+		i, _ = g.index.match(g, path, precedence)
+	} else {
+		i, _ = matchRuleSet(g, path, precedence, nil)
+	}
+
+	return i < 0 || g.rules[i].isAllowed
+}
+
+func (g *compactGroup) ruleCount() int          { return len(g.rules) }
+func (g *compactGroup) rulePath(i int) string   { return g.paths[g.rules[i].start:g.rules[i].end] }
+func (g *compactGroup) ruleAllowed(i int) bool  { return g.rules[i].isAllowed }
+func (g *compactGroup) ruleWildcard(i int) bool { return g.rules[i].pattern != nil }
+
+func (g *compactGroup) ruleMatches(i int, path string) bool {
+	if pattern := g.rules[i].pattern; pattern != nil {
+		return pattern.match(path)
+	}
+
+	return strings.HasPrefix(path, g.rulePath(i))
+}
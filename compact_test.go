@@ -0,0 +1,180 @@
+package robotstxt
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCompact_sameDecisions(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := append([]string{
+		largeRobotsTxt(100),
+		`
+			User-agent: Googlebot
+			User-agent: Bingbot
+			Disallow: /private/
+			Allow: /private/ok
+			Crawl-delay: 3
+
+			User-agent: *
+			Disallow: /*.pdf$
+			Disallow: /wp-admin/
+			Allow: /wp-admin/admin-ajax.php
+		`,
+	}, roundTripContents...)
+
+	paths := []string{
+		"/", "/fish/", "/fish/index.php", "/private/ok", "/private/no", "/a.pdf",
+		"/wp-admin/", "/wp-admin/admin-ajax.php", "/section-10/private/index.html",
+		"/section-11/private/x", "/%24%A/test", "/%E6%B5%8B%E8%AF%95", "/?sessionid=1",
+	}
+
+	for _, c := range contents {
+		for _, opts := range [][]Option{nil, {WithPrecedence(LegacyOrder)}} {
+			robots, _ := Parse(c, url, opts...)
+			compact := robots.Compact()
+
+			for _, userAgent := range []string{"*", "googlebot", "Bingbot/2.0", "agentb", "other"} {
+				for _, path := range paths {
+					expected, _ := robots.IsAllowed(userAgent, "http://www.example.com"+path)
+					actual, _ := compact.IsAllowed(userAgent, "http://www.example.com"+path)
+					if expected != actual {
+						t.Errorf("Expected %s for %s to be %v, got %v in:\n%s", path, userAgent, expected, actual, c)
+					}
+				}
+
+				if robots.CrawlDelay(userAgent) != compact.CrawlDelay(userAgent) {
+					t.Errorf("Expected crawl delay %v for %s, got %v", robots.CrawlDelay(userAgent), userAgent, compact.CrawlDelay(userAgent))
+				}
+			}
+
+			if strings.Join(robots.Sitemaps(), ",") != strings.Join(compact.Sitemaps(), ",") || robots.Host() != compact.Host() {
+				t.Errorf("Expected the same sitemaps and host for:\n%s", c)
+			}
+		}
+	}
+}
+
+func TestParseBytes_doesNotKeepContents(t *testing.T) {
+	contents := []byte("User-agent: Bot\nDisallow: /*.pdf$\nDisallow: /private\nSitemap: http://www.example.com/sitemap.xml\nHost: example.com\n")
+
+	compact, err := ParseBytes(contents, "http://www.example.com/robots.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range contents {
+		contents[i] = 'x'
+	}
+
+	if allowed, _ := compact.IsAllowed("bot", "http://www.example.com/private"); allowed {
+		t.Errorf("Expected /private to be disallowed")
+	}
+
+	if allowed, _ := compact.IsAllowed("bot", "http://www.example.com/a.pdf"); allowed {
+		t.Errorf("Expected /a.pdf to be disallowed")
+	}
+
+	if compact.Sitemaps()[0] != "http://www.example.com/sitemap.xml" || compact.Host() != "example.com" {
+		t.Errorf("Expected the sitemap and host to be copied, got %v and %q", compact.Sitemaps(), compact.Host())
+	}
+
+	if _, err := compact.IsAllowed("bot", "http://other.example.com/"); err == nil {
+		t.Errorf("Expected an error for a URL on another host")
+	}
+}
+
+func TestCompact_sharePatterns(t *testing.T) {
+	a, _ := ParseBytes([]byte("User-agent: *\nDisallow: /*.pdf$\n"), "http://a.example.com/robots.txt")
+	b, _ := ParseBytes([]byte("User-agent: *\nDisallow: /*.pdf$\n"), "http://b.example.com/robots.txt")
+
+	if a.groups[0].rules[0].pattern != b.groups[0].rules[0].pattern {
+		t.Errorf("Expected the compiled pattern to be shared")
+	}
+}
+
+// siteRobotsTxt returns a typical robots.txt file for site i
+func siteRobotsTxt(i int) []byte {
+	return []byte(fmt.Sprintf(`# robots.txt for site %d
+User-agent: *
+Disallow: /wp-admin/
+Allow: /wp-admin/admin-ajax.php
+Disallow: /*?replytocom=
+Disallow: /search/
+Disallow: /private-%d/
+
+User-agent: Googlebot
+User-agent: Bingbot
+Disallow: /*.pdf$
+Disallow: /tmp/
+Crawl-delay: 2
+
+Sitemap: https://site-%d.example.com/sitemap.xml
+`, i, i, i))
+}
+
+func benchmarkRetained(b *testing.B, parse func(i int) interface{}) {
+	const sites = 1000
+
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		kept := make([]interface{}, sites)
+		for i := range kept {
+			kept[i] = parse(i)
+		}
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		runtime.KeepAlive(kept)
+
+		b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/sites, "retained-B/file")
+	}
+}
+
+func BenchmarkMemory(b *testing.B) {
+	b.Run("RobotsTxt", func(b *testing.B) {
+		benchmarkRetained(b, func(i int) interface{} {
+			robots, _ := Parse(string(siteRobotsTxt(i)), fmt.Sprintf("https://site-%d.example.com/robots.txt", i))
+			return robots
+		})
+	})
+
+	b.Run("Compact", func(b *testing.B) {
+		benchmarkRetained(b, func(i int) interface{} {
+			compact, _ := ParseBytes(siteRobotsTxt(i), fmt.Sprintf("https://site-%d.example.com/robots.txt", i))
+			return compact
+		})
+	})
+}
+
+func BenchmarkParse(b *testing.B) {
+	contents := siteRobotsTxt(1)
+	url := "https://site-1.example.com/robots.txt"
+
+	b.Run("Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Parse(string(contents), url)
+		}
+	})
+
+	b.Run("ParseReader", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ParseReader(strings.NewReader(string(contents)), url)
+		}
+	})
+
+	b.Run("ParseBytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ParseBytes(contents, url)
+		}
+	})
+}
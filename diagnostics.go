@@ -0,0 +1,91 @@
+package robotstxt
+
+import "fmt"
+
+// Severity is how serious a Diagnostic is
+type Severity int
+
+const (
+	// SeverityWarning is for lines that were ignored or are likely
+	// not doing what the author intended
+	SeverityWarning Severity = iota
+
+	// SeverityError is for lines that could not be parsed at all
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+
+	return "unknown"
+}
+
+// DiagnosticCode is a machine readable identifier for a Diagnostic
+type DiagnosticCode string
+
+const (
+	// CodeInvalidLine is a non-empty line that is not a comment and
+	// is not in the form "directive: value"
+	CodeInvalidLine DiagnosticCode = "invalid-line"
+
+	// CodeUnknownDirective is a directive this package does not support
+	CodeUnknownDirective DiagnosticCode = "unknown-directive"
+
+	// CodeOutsideGroup is a rule or crawl-delay that is not preceded
+	// by a user-agent line
+	CodeOutsideGroup DiagnosticCode = "outside-group"
+
+	// CodeEmptyValue is a directive that requires a value but has none
+	CodeEmptyValue DiagnosticCode = "empty-value"
+
+	// CodeInvalidCrawlDelay is a crawl-delay that is not a number or
+	// is negative or too large
+	CodeInvalidCrawlDelay DiagnosticCode = "invalid-crawl-delay"
+
+	// CodeRelativePath is an allow or disallow path that does not start
+	// with a / and has been treated as if it did
+	CodeRelativePath DiagnosticCode = "relative-path"
+
+	// CodeAbsoluteURL is an allow or disallow value that is an absolute
+	// URL and has been reduced to its path
+	CodeAbsoluteURL DiagnosticCode = "absolute-url"
+
+	// CodeTruncated is reported on the first line that was not parsed
+	// because the file exceeded the size limit
+	CodeTruncated DiagnosticCode = "truncated"
+)
+
+// Diagnostic describes a problem found while parsing a robots.txt file
+type Diagnostic struct {
+	// Line is the 1-based line number of the problem
+	Line int
+	// Column is the 1-based byte offset of the problem within the line
+	Column int
+	// Severity is how serious the problem is
+	Severity Severity
+	// Directive is the lower case directive name, empty if the line
+	// did not contain one
+	Directive string
+	// Code is a machine readable identifier for the problem
+	Code DiagnosticCode
+	// Message is a human readable description of the problem
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", d.Line, d.Column, d.Severity, d.Message, d.Code)
+}
+
+// Diagnostics returns the warnings and errors found while parsing
+// the robots.txt file in the order they occurred
+func (r *RobotsTxt) Diagnostics() []Diagnostic {
+	diagnostics := make([]Diagnostic, len(r.diagnostics))
+	copy(diagnostics, r.diagnostics)
+
+	return diagnostics
+}
@@ -0,0 +1,106 @@
+package robotstxt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRobotsTxt_reportDiagnostics(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := "Disallow: /outside\n" +
+		"invalid line\n" +
+		"# comment: ignored\n" +
+		"User-agent: *\n" +
+		"Disallow: /fish\n" +
+		"  Crawl-delay:  1.a0\n" +
+		"Unknown: value\n" +
+		":::::\n" +
+		"Sitemap:\n" +
+		"\n" +
+		"User-agent:\n"
+
+	robots, err := Parse(contents, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Diagnostic{
+		{Line: 1, Column: 1, Severity: SeverityWarning, Directive: "disallow", Code: CodeOutsideGroup},
+		{Line: 2, Column: 1, Severity: SeverityError, Directive: "", Code: CodeInvalidLine},
+		{Line: 6, Column: 17, Severity: SeverityWarning, Directive: "crawl-delay", Code: CodeInvalidCrawlDelay},
+		{Line: 7, Column: 1, Severity: SeverityWarning, Directive: "unknown", Code: CodeUnknownDirective},
+		{Line: 8, Column: 1, Severity: SeverityError, Directive: "", Code: CodeInvalidLine},
+		{Line: 9, Column: 9, Severity: SeverityWarning, Directive: "sitemap", Code: CodeEmptyValue},
+		{Line: 11, Column: 12, Severity: SeverityWarning, Directive: "user-agent", Code: CodeEmptyValue},
+	}
+
+	actual := robots.Diagnostics()
+	for i := range actual {
+		if actual[i].Message == "" {
+			t.Errorf("Expected diagnostic %d to have a message", i)
+		}
+		actual[i].Message = ""
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected diagnostics %v, got %v", expected, actual)
+	}
+
+	// Changing the returned diagnostics must not change the parsed file
+	for i, diagnostic := range robots.Diagnostics() {
+		if diagnostic.Message == "" {
+			t.Errorf("Expected diagnostic %d to still have a message", i)
+		}
+	}
+}
+
+func TestRobotsTxt_reportInvalidCrawlDelays(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+
+	for _, delay := range []string{"NaN", "Inf", "-Inf", "-5", "1e300", "1e10"} {
+		robots, _ := Parse("User-agent: *\nCrawl-delay: "+delay+"\n", url)
+
+		diagnostics := robots.Diagnostics()
+		if len(diagnostics) != 1 || diagnostics[0].Code != CodeInvalidCrawlDelay {
+			t.Errorf("Expected an invalid crawl delay diagnostic for %q, got %v", delay, diagnostics)
+		}
+
+		if crawlDelay := robots.CrawlDelay("bot"); crawlDelay != 0 {
+			t.Errorf("Expected no crawl delay for %q, got %v", delay, crawlDelay)
+		}
+	}
+}
+
+func TestRobotsTxt_noDiagnosticsForValidFile(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := `
+		# Comment
+		User-agent: *
+		Disallow: /fish/
+		Allow: /fish/index.php
+		Crawl-delay: 1
+		Sitemap: http://www.example.com/sitemap.xml
+	`
+
+	robots, _ := Parse(contents, url)
+
+	if len(robots.Diagnostics()) != 0 {
+		t.Errorf("Expected no diagnostics, got %v", robots.Diagnostics())
+	}
+}
+
+func TestRobotsTxt_acceptInvalidUTF8Patterns(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := "User-agent: a\nUser-agent: b\nDisallow: /\xff*\n"
+
+	robots, _ := Parse(contents, url)
+
+	if diagnostics := robots.Diagnostics(); len(diagnostics) != 0 {
+		t.Fatalf("Expected no diagnostics, got %v", diagnostics)
+	}
+
+	allowed, _ := robots.IsAllowed("a", "http://www.example.com/%FFtest")
+	if allowed {
+		t.Errorf("Expected /%%FFtest to be disallowed")
+	}
+}
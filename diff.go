@@ -0,0 +1,195 @@
+package robotstxt
+
+import (
+	"sort"
+	"time"
+)
+
+// maxExamples is the most example paths given for each PathChange
+const maxExamples = 3
+
+// Changes is what changed between two versions of a robots.txt file
+type Changes struct {
+	// UserAgents are the user agents whose rules or crawl delay
+	// changed, sorted by user agent. The * user agent covers every
+	// user agent that is not named in either version.
+	UserAgents []UserAgentChanges
+	// AddedSitemaps are sitemaps only in the new version
+	AddedSitemaps []string
+	// RemovedSitemaps are sitemaps only in the old version
+	RemovedSitemaps []string
+	// OldHost and NewHost are the preferred hosts. They are equal if
+	// the host did not change.
+	OldHost, NewHost string
+	// Complete is false if the rules were too complex to compare
+	// every path, in which case some path changes may be missing
+	Complete bool
+}
+
+// UserAgentChanges is what changed for a single user agent
+type UserAgentChanges struct {
+	// UserAgent is the normalised user agent
+	UserAgent string
+	// Paths are the paths that are now decided differently
+	Paths []PathChange
+	// OldCrawlDelay and NewCrawlDelay are the crawl delays. They are
+	// equal if the crawl delay did not change.
+	OldCrawlDelay, NewCrawlDelay time.Duration
+}
+
+// PathChange is a set of paths that became allowed or disallowed
+// because the rule deciding them changed
+type PathChange struct {
+	// Allowed is true if the paths became allowed and false if they
+	// became disallowed
+	Allowed bool
+	// OldRule is the rule that decided the paths in the old version
+	// or nil if no rule matched them
+	OldRule *Rule
+	// NewRule is the rule that decides the paths in the new version
+	// or nil if no rule matches them
+	NewRule *Rule
+	// Examples are paths, with any query string, that the versions
+	// decide differently, shortest first. They are escaped so they can
+	// be added to the scheme and host of the robots.txt file to make a
+	// URL.
+	Examples []string
+}
+
+// Empty returns true if nothing changed
+func (c *Changes) Empty() bool {
+	return len(c.UserAgents) == 0 && len(c.AddedSitemaps) == 0 &&
+		len(c.RemovedSitemaps) == 0 && c.OldHost == c.NewHost
+}
+
+// Diff returns what changed for crawlers between from, the old version
+// of a robots.txt file, and to, the new version. Rather than comparing
+// the text it compares the decisions each version makes so reordering
+// rules or changing comments is not a change.
+func Diff(from, to *RobotsTxt) *Changes {
+	changes := &Changes{
+		AddedSitemaps:   missingStrings(to.sitemaps, from.sitemaps),
+		RemovedSitemaps: missingStrings(from.sitemaps, to.sitemaps),
+		OldHost:         from.host,
+		NewHost:         to.host,
+		Complete:        true,
+	}
+
+	for _, userAgent := range diffUserAgents(from, to) {
+		_, fromGroup := from.findGroup(userAgent)
+		_, toGroup := to.findGroup(userAgent)
+
+		uaChanges := UserAgentChanges{
+			UserAgent:     userAgent,
+			OldCrawlDelay: from.CrawlDelay(userAgent),
+			NewCrawlDelay: to.CrawlDelay(userAgent),
+		}
+
+		paths, complete := diffGroups(fromGroup, from.precedence, toGroup, to.precedence)
+		uaChanges.Paths = paths
+		changes.Complete = changes.Complete && complete
+
+		if len(paths) > 0 || uaChanges.OldCrawlDelay != uaChanges.NewCrawlDelay {
+			changes.UserAgents = append(changes.UserAgents, uaChanges)
+		}
+	}
+
+	return changes
+}
+
+// diffGroups returns the paths the groups decide differently. Either
+// group may be nil if there is no group for the user agent.
+func diffGroups(from *group, fromPrecedence Precedence, to *group, toPrecedence Precedence) ([]PathChange, bool) {
+	if from == nil {
+		from = &group{}
+	}
+
+	if to == nil {
+		to = &group{}
+	}
+
+	type changeKey struct {
+		from, to *rule
+	}
+
+	var paths []PathChange
+	index := make(map[changeKey]int)
+
+	rules := append(append([]*rule{}, from.rules...), to.rules...)
+	complete := explorePaths(rules, func(path string) bool {
+		fromRule, _ := from.match(path, fromPrecedence, nil)
+		toRule, _ := to.match(path, toPrecedence, nil)
+
+		allowed := toRule == nil || toRule.isAllowed
+		if allowed == (fromRule == nil || fromRule.isAllowed) {
+			return true
+		}
+
+		key := changeKey{fromRule, toRule}
+		i, ok := index[key]
+		if !ok {
+			i = len(paths)
+			index[key] = i
+			paths = append(paths, PathChange{
+				Allowed: allowed,
+				OldRule: ruleModelOrNil(fromRule),
+				NewRule: ruleModelOrNil(toRule),
+			})
+		}
+
+		if len(paths[i].Examples) < maxExamples {
+			paths[i].Examples = append(paths[i].Examples, escapeRulePath(path, false))
+		}
+
+		return true
+	})
+
+	return paths, complete
+}
+
+// diffUserAgents returns * and every user agent with a group in
+// either version, sorted
+func diffUserAgents(from, to *RobotsTxt) []string {
+	seen := map[string]bool{"*": true}
+	userAgents := []string{"*"}
+
+	for _, r := range []*RobotsTxt{from, to} {
+		for userAgent := range r.groups {
+			if !seen[userAgent] {
+				seen[userAgent] = true
+				userAgents = append(userAgents, userAgent)
+			}
+		}
+	}
+
+	sort.Strings(userAgents)
+
+	return userAgents
+}
+
+func ruleModelOrNil(rule *rule) *Rule {
+	if rule == nil {
+		return nil
+	}
+
+	model := newRuleModel(rule)
+
+	return &model
+}
+
+// missingStrings returns the strings in a that are not in b
+func missingStrings(a, b []string) []string {
+	inB := make(map[string]bool)
+	for _, s := range b {
+		inB[s] = true
+	}
+
+	var missing []string
+	for _, s := range a {
+		if !inB[s] {
+			missing = append(missing, s)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,186 @@
+package robotstxt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiff_pathChanges(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	from, _ := Parse(`
+		User-agent: *
+		Disallow: /private/
+		Disallow: /tmp/
+
+		User-agent: a
+		Disallow: /
+		Crawl-delay: 1
+	`, url)
+	to, _ := Parse(`
+		# Reordered with a comment
+		User-agent: *
+		Disallow: /tmp/
+		Disallow: /private/
+		Allow: /private/public/
+		Disallow: /*.pdf$
+
+		User-agent: a
+		Disallow: /
+		Crawl-delay: 2
+	`, url)
+
+	changes := Diff(from, to)
+	if !changes.Complete {
+		t.Fatalf("Expected a complete diff")
+	}
+
+	if len(changes.UserAgents) != 2 {
+		t.Fatalf("Expected changes for * and a, got %+v", changes.UserAgents)
+	}
+
+	a := changes.UserAgents[1]
+	if a.UserAgent != "a" || len(a.Paths) != 0 || a.OldCrawlDelay != time.Second || a.NewCrawlDelay != 2*time.Second {
+		t.Errorf("Expected only the crawl delay of a to change, got %+v", a)
+	}
+
+	all := changes.UserAgents[0]
+	if all.UserAgent != "*" || len(all.Paths) != 2 {
+		t.Fatalf("Expected two path changes for *, got %+v", all)
+	}
+
+	public := all.Paths[1]
+	if !public.Allowed || public.OldRule.Path != "/private/" || public.NewRule.Path != "/private/public/" {
+		t.Errorf("Expected /private/public/ to become allowed, got %+v", public)
+	}
+
+	pdf := all.Paths[0]
+	if pdf.Allowed || pdf.OldRule != nil || pdf.NewRule.Path != "/*.pdf$" {
+		t.Errorf("Expected PDFs to become disallowed, got %+v", pdf)
+	}
+
+	for _, change := range all.Paths {
+		if len(change.Examples) == 0 {
+			t.Errorf("Expected examples for %+v", change)
+		}
+
+		for _, example := range change.Examples {
+			fromAllowed, _ := from.IsAllowed("b", "http://www.example.com"+example)
+			toAllowed, _ := to.IsAllowed("b", "http://www.example.com"+example)
+			if fromAllowed == toAllowed || toAllowed != change.Allowed {
+				t.Errorf("Expected %q to be decided differently", example)
+			}
+		}
+	}
+}
+
+func TestDiff_noChanges(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	from, _ := Parse("User-agent: *\nDisallow: /a\nDisallow: /a\nAllow: /a/b\n", url)
+	to, _ := Parse("User-agent: *\nAllow: /a/b\nDisallow: /a\n", url)
+
+	if changes := Diff(from, to); !changes.Empty() {
+		t.Errorf("Expected no changes, got %+v", changes)
+	}
+}
+
+func TestDiff_sitemapsAndHost(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	from, _ := Parse("Sitemap: http://www.example.com/a.xml\nSitemap: http://www.example.com/b.xml\nHost: a.example.com\n", url)
+	to, _ := Parse("Sitemap: http://www.example.com/b.xml\nSitemap: http://www.example.com/c.xml\nHost: b.example.com\n", url)
+
+	changes := Diff(from, to)
+	if !reflect.DeepEqual(changes.AddedSitemaps, []string{"http://www.example.com/c.xml"}) {
+		t.Errorf("Expected c.xml to be added, got %v", changes.AddedSitemaps)
+	}
+
+	if !reflect.DeepEqual(changes.RemovedSitemaps, []string{"http://www.example.com/a.xml"}) {
+		t.Errorf("Expected a.xml to be removed, got %v", changes.RemovedSitemaps)
+	}
+
+	if changes.OldHost != "a.example.com" || changes.NewHost != "b.example.com" {
+		t.Errorf("Expected the host to change, got %q and %q", changes.OldHost, changes.NewHost)
+	}
+}
+
+func TestDiff_wildcardExamples(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	tests := []struct {
+		from, to string
+	}{
+		{"Disallow: /*a*b", "Disallow: /*a*c"},
+		{"Disallow: /x*y$", "Disallow: /x*y"},
+		{"Disallow: /price%24", "Disallow: /price$"},
+		{"Disallow: /a*%2A", "Disallow: /a*"},
+		{"Disallow: /a", "Disallow: /a\nAllow: /*b"},
+		{"Disallow: /a", "Disallow: /a\nAllow: /a/100%"},
+		{"Disallow: /a", "Disallow: /a\nAllow: /a/b c"},
+		{"Disallow: /a", "Disallow: /a\nAllow: /a/%E6"},
+		{"Disallow: /a", "Disallow: /a\nAllow: /a/%3F"},
+		{"Disallow: /a", "Disallow: /a\nAllow: /a/%2A"},
+	}
+
+	for _, test := range tests {
+		from, _ := Parse("User-agent: *\n"+test.from, url)
+		to, _ := Parse("User-agent: *\n"+test.to, url)
+
+		changes := Diff(from, to)
+		if len(changes.UserAgents) != 1 || len(changes.UserAgents[0].Paths) == 0 {
+			t.Errorf("Expected changes from %q to %q, got %+v", test.from, test.to, changes)
+			continue
+		}
+
+		for _, change := range changes.UserAgents[0].Paths {
+			for _, example := range change.Examples {
+				fromAllowed, fromErr := from.IsAllowed("bot", "http://www.example.com"+example)
+				toAllowed, toErr := to.IsPathAllowed("bot", example)
+				if fromErr != nil || toErr != nil {
+					t.Errorf("Expected %q to be a valid path, got %v, %v", example, fromErr, toErr)
+				} else if fromAllowed == toAllowed || toAllowed != change.Allowed {
+					t.Errorf("Expected %q to be decided differently by %q and %q", example, test.from, test.to)
+				}
+			}
+		}
+	}
+}
+
+func TestDiff_largeFiles(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := largeRobotsTxt(5000)
+	from, _ := Parse(contents, url)
+	to, _ := Parse(strings.Replace(contents, "Disallow: /section-4000/private/\n", "", 1), url)
+
+	changes := Diff(from, to)
+	if !changes.Complete {
+		t.Fatalf("Expected a complete diff")
+	}
+
+	if len(changes.UserAgents) != 1 || len(changes.UserAgents[0].Paths) != 1 {
+		t.Fatalf("Expected a single path change, got %+v", changes.UserAgents)
+	}
+
+	if change := changes.UserAgents[0].Paths[0]; !change.Allowed || change.OldRule.Path != "/section-4000/private/" {
+		t.Errorf("Expected /section-4000/private/ to become allowed, got %+v", change)
+	}
+}
+
+func BenchmarkDiff(b *testing.B) {
+	url := "http://www.example.com/robots.txt"
+	contents := largeRobotsTxt(2000)
+
+	from, _ := Parse(contents, url)
+	to, _ := Parse(strings.Replace(contents, "Disallow: /section-1000/private/\n", "Disallow: /section-1000/\n", 1), url)
+
+	b.Run("Diff", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Diff(from, to)
+		}
+	})
+
+	b.Run("Equivalent", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Equivalent(from, to, "*")
+		}
+	})
+}
@@ -0,0 +1,412 @@
+package robotstxt
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrNotRule is returned when editing a line that is not an allow
+	// or disallow rule
+	ErrNotRule = errors.New("robotstxt: line is not a rule")
+
+	// ErrNotGroup is returned when adding a rule to a line that is not
+	// a user-agent line
+	ErrNotGroup = errors.New("robotstxt: line is not a user-agent line")
+)
+
+// Document is a lossless robots.txt file that keeps comments, blank
+// lines, ordering, casing and line endings so it can be edited and
+// written back out. Lines that are not edited are written exactly as
+// they were read.
+//
+// Lines are referred to by their 1-based line number, the same numbers
+// used by Groups, Rules and Diagnostics of the RobotsTxt returned by
+// Compile. Edits that add or remove lines change the numbers of the
+// lines after them.
+type Document struct {
+	lines   []*documentLine
+	newline string
+}
+
+// documentLine is a line of a Document
+type documentLine struct {
+	// text is the line without its line ending
+	text string
+	// ending is the line ending or "" for the last line if the
+	// file does not end with one
+	ending string
+	// name is the lower case directive name or "" if the line
+	// is not a directive
+	name string
+	// value is the value of the directive
+	value string
+}
+
+// documentGroup is the lines of a group in a Document
+type documentGroup struct {
+	userAgents []int
+	rules      []int
+}
+
+// ParseDocument parses the contents of a robots.txt file into a Document
+func ParseDocument(contents string) *Document {
+	d := &Document{newline: "\n"}
+
+	foundNewline := false
+	for len(contents) > 0 {
+		text, ending := contents, ""
+		if i := strings.IndexAny(contents, "\r\n"); i > -1 {
+			text, ending = contents[:i], contents[i:i+1]
+			if strings.HasPrefix(contents[i:], "\r\n") {
+				ending = "\r\n"
+			}
+		}
+
+		if !foundNewline && ending != "" {
+			d.newline = ending
+			foundNewline = true
+		}
+
+		contents = contents[len(text)+len(ending):]
+		d.lines = append(d.lines, newDocumentLine(text, ending, len(d.lines) == 0))
+	}
+
+	return d
+}
+
+func newDocumentLine(text, ending string, isFirst bool) *documentLine {
+	line := &documentLine{text: text, ending: ending}
+
+	directive := text
+	if isFirst {
+		directive = strings.TrimPrefix(directive, byteOrderMark)
+	}
+
+	if index := strings.IndexByte(directive, '#'); index > -1 {
+		directive = directive[:index]
+	}
+
+	parts := strings.SplitN(directive, ":", 2)
+	if name := strings.TrimSpace(parts[0]); len(parts) == 2 && name != "" {
+		line.name = strings.ToLower(name)
+		line.value = strings.TrimSpace(parts[1])
+	}
+
+	return line
+}
+
+// String returns the robots.txt file
+func (d *Document) String() string {
+	var sb strings.Builder
+	d.WriteTo(&sb)
+
+	return sb.String()
+}
+
+// WriteTo writes the robots.txt file to w
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	for _, line := range d.lines {
+		sb.WriteString(line.text)
+		sb.WriteString(line.ending)
+	}
+
+	n, err := io.WriteString(w, sb.String())
+
+	return int64(n), err
+}
+
+// Compile parses the document for the robots.txt URL urlStr
+func (d *Document) Compile(urlStr string, opts ...Option) (*RobotsTxt, error) {
+	return Parse(d.String(), urlStr, opts...)
+}
+
+// AddRule adds a rule to the end of the rules of the group with a
+// user-agent line on groupLine, such as Group.Line. The new line copies
+// the indentation and casing of the group and its line number is
+// returned. Paths are checked the same way as Builder.Disallow.
+func (d *Document) AddRule(groupLine int, allowed bool, path string) (int, error) {
+	if reason := checkRulePath(path); reason != "" {
+		return 0, &BuilderError{Directive: ruleDirective(allowed), Value: path, Reason: reason}
+	}
+
+	group := d.findGroup(groupLine - 1)
+	if group == nil {
+		return 0, ErrNotGroup
+	}
+
+	after := group.userAgents[len(group.userAgents)-1]
+	if len(group.rules) > 0 {
+		after = group.rules[len(group.rules)-1]
+	}
+
+	ref := d.lines[after]
+	name := matchCase(ref.directiveName(), ruleDirective(allowed))
+	d.insertLine(after+1, ref.indent()+name+": "+path)
+
+	return after + 2, nil
+}
+
+// RemoveRule removes the allow or disallow rule on line
+func (d *Document) RemoveRule(line int) error {
+	if !d.isRule(line - 1) {
+		return ErrNotRule
+	}
+
+	d.removeLine(line - 1)
+
+	return nil
+}
+
+// ReplaceRule replaces the rule on line keeping its indentation and
+// any comment. Paths are checked the same way as Builder.Disallow.
+func (d *Document) ReplaceRule(line int, allowed bool, path string) error {
+	if reason := checkRulePath(path); reason != "" {
+		return &BuilderError{Directive: ruleDirective(allowed), Value: path, Reason: reason}
+	}
+
+	if !d.isRule(line - 1) {
+		return ErrNotRule
+	}
+
+	l := d.lines[line-1]
+	if directive := ruleDirective(allowed); l.name != directive {
+		l.setName(directive)
+	}
+	l.setValue(path)
+
+	return nil
+}
+
+// AddGroup adds a group for the user agents to the end of the document
+// and returns the line number of its first user-agent line. User agents
+// are checked the same way as Builder.Group.
+func (d *Document) AddGroup(userAgents ...string) (int, error) {
+	if len(userAgents) == 0 {
+		return 0, &BuilderError{Directive: "user-agent", Reason: "a group needs at least one user agent"}
+	}
+
+	for _, userAgent := range userAgents {
+		if reason := checkUserAgent(userAgent); reason != "" {
+			return 0, &BuilderError{Directive: "user-agent", Value: userAgent, Reason: reason}
+		}
+	}
+
+	// A user-agent line straight after another would join its group
+	// so end a group with no rules with an empty rule that matches
+	// nothing
+	if last := d.lastDirective(); last != nil && last.name == "user-agent" {
+		d.insertLine(len(d.lines), "Disallow:")
+	}
+
+	if len(d.lines) > 0 && strings.TrimSpace(d.lines[len(d.lines)-1].text) != "" {
+		d.insertLine(len(d.lines), "")
+	}
+
+	start := len(d.lines)
+	for _, userAgent := range userAgents {
+		d.insertLine(len(d.lines), "User-agent: "+userAgent)
+	}
+
+	return start + 1, nil
+}
+
+// SetSitemaps sets the sitemaps of the document. Existing sitemap
+// lines are reused in order, any extra are removed and any new sitemaps
+// are added after the last existing sitemap line or to the end of the
+// document. Sitemap URLs are checked the same way as Builder.Sitemap.
+func (d *Document) SetSitemaps(sitemapURLs ...string) error {
+	for _, sitemapURL := range sitemapURLs {
+		if reason := checkSitemap(sitemapURL); reason != "" {
+			return &BuilderError{Directive: "sitemap", Value: sitemapURL, Reason: reason}
+		}
+	}
+
+	var existing []int
+	for i, line := range d.lines {
+		if line.name == "sitemap" {
+			existing = append(existing, i)
+		}
+	}
+
+	for i := len(existing) - 1; i >= len(sitemapURLs); i-- {
+		d.removeLine(existing[i])
+		existing = existing[:i]
+	}
+
+	for i, sitemapURL := range sitemapURLs[:len(existing)] {
+		d.lines[existing[i]].setValue(sitemapURL)
+	}
+
+	after := len(d.lines)
+	if len(existing) > 0 {
+		after = existing[len(existing)-1] + 1
+	}
+
+	for _, sitemapURL := range sitemapURLs[len(existing):] {
+		d.insertLine(after, "Sitemap: "+sitemapURL)
+		after++
+	}
+
+	return nil
+}
+
+// groups returns the groups of the document, found the same way
+// as the parser does
+func (d *Document) groups() []*documentGroup {
+	var groups []*documentGroup
+	var current *documentGroup
+	isNoneUserAgentState := false
+
+	for i, line := range d.lines {
+		switch line.name {
+		case "":
+			continue
+		case "user-agent":
+			if isNoneUserAgentState || current == nil {
+				current = &documentGroup{}
+				groups = append(groups, current)
+			}
+			current.userAgents = append(current.userAgents, i)
+		case "allow", "disallow":
+			if current != nil {
+				current.rules = append(current.rules, i)
+			}
+		}
+
+		isNoneUserAgentState = line.name != "user-agent"
+	}
+
+	return groups
+}
+
+// findGroup returns the group with a user-agent line at index
+func (d *Document) findGroup(index int) *documentGroup {
+	for _, group := range d.groups() {
+		for _, userAgent := range group.userAgents {
+			if userAgent == index {
+				return group
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *Document) isRule(index int) bool {
+	if index < 0 || index >= len(d.lines) {
+		return false
+	}
+
+	name := d.lines[index].name
+
+	return name == "allow" || name == "disallow"
+}
+
+func (d *Document) lastDirective() *documentLine {
+	for i := len(d.lines) - 1; i >= 0; i-- {
+		if d.lines[i].name != "" {
+			return d.lines[i]
+		}
+	}
+
+	return nil
+}
+
+// insertLine inserts a line before index keeping the file ending the
+// same way it did before
+func (d *Document) insertLine(index int, text string) {
+	line := newDocumentLine(text, d.newline, index == 0)
+
+	if index == len(d.lines) && index > 0 && d.lines[index-1].ending == "" {
+		d.lines[index-1].ending = d.newline
+		line.ending = ""
+	}
+
+	d.lines = append(d.lines, nil)
+	copy(d.lines[index+1:], d.lines[index:])
+	d.lines[index] = line
+}
+
+// removeLine removes the line at index keeping the file ending the
+// same way it did before
+func (d *Document) removeLine(index int) {
+	if index == len(d.lines)-1 && index > 0 && d.lines[index].ending == "" {
+		d.lines[index-1].ending = ""
+	}
+
+	d.lines = append(d.lines[:index], d.lines[index+1:]...)
+}
+
+// directiveName returns the directive name as written
+func (l *documentLine) directiveName() string {
+	text := strings.TrimPrefix(l.text, byteOrderMark)
+
+	return strings.TrimSpace(text[:strings.IndexByte(text, ':')])
+}
+
+// indent returns the whitespace at the start of the line
+func (l *documentLine) indent() string {
+	text := strings.TrimPrefix(l.text, byteOrderMark)
+
+	return text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+}
+
+// setName replaces the directive name keeping the casing style
+func (l *documentLine) setName(name string) {
+	current := l.directiveName()
+	start := strings.Index(l.text, current)
+
+	l.text = l.text[:start] + matchCase(current, name) + l.text[start+len(current):]
+	l.name = name
+}
+
+// setValue replaces the value keeping the whitespace and comment
+// around it
+func (l *documentLine) setValue(value string) {
+	colon := strings.IndexByte(l.text, ':')
+	end := len(l.text)
+	if index := strings.IndexByte(l.text, '#'); index > colon {
+		end = index
+	}
+
+	region := l.text[colon+1 : end]
+	trimmed := strings.TrimSpace(region)
+
+	var before, after string
+	if trimmed == "" {
+		before, after = " ", region
+		if end == len(l.text) {
+			after = ""
+		}
+	} else {
+		start := strings.Index(region, trimmed)
+		before, after = region[:start], region[start+len(trimmed):]
+	}
+
+	l.text = l.text[:colon+1] + before + value + after + l.text[end:]
+	l.value = value
+}
+
+func ruleDirective(allowed bool) string {
+	if allowed {
+		return "allow"
+	}
+
+	return "disallow"
+}
+
+// matchCase returns name, a lower case directive name, in the same
+// casing style as example
+func matchCase(example, name string) string {
+	switch example {
+	case strings.ToLower(example):
+		return name
+	case strings.ToUpper(example):
+		return strings.ToUpper(name)
+	}
+
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,164 @@
+package robotstxt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const documentContents = "\uFEFF# Example robots.txt\r\n" +
+	"\r\n" +
+	"USER-AGENT: Googlebot   # Google\r\n" +
+	"  DISALLOW: /private/   # keep out\r\n" +
+	"  Crawl-delay: 2\r\n" +
+	"\r\n" +
+	"user-agent: *\r\n" +
+	"disallow:\r\n" +
+	"\r\n" +
+	"Sitemap: http://www.example.com/a.xml\r\n" +
+	"Sitemap: http://www.example.com/b.xml"
+
+func TestDocument_losslessRoundTrip(t *testing.T) {
+	contents := []string{
+		"",
+		"\n",
+		documentContents,
+		"User-agent: *\rDisallow: /\r",
+		"User-agent: *\n\tDisallow: /fish # comment\n\n\ninvalid line\n",
+	}
+
+	for _, c := range contents {
+		if actual := ParseDocument(c).String(); actual != c {
+			t.Errorf("Expected %q, got %q", c, actual)
+		}
+	}
+}
+
+func TestDocument_editRules(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	d := ParseDocument(documentContents)
+
+	if err := d.ReplaceRule(4, true, "/private/ok"); err != nil {
+		t.Fatal(err)
+	}
+
+	line, err := d.AddRule(3, false, "/tmp/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if line != 5 {
+		t.Errorf("Expected the rule to be added on line 5, got %d", line)
+	}
+
+	if err := d.ReplaceRule(9, false, "/"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "\uFEFF# Example robots.txt\r\n" +
+		"\r\n" +
+		"USER-AGENT: Googlebot   # Google\r\n" +
+		"  ALLOW: /private/ok   # keep out\r\n" +
+		"  DISALLOW: /tmp/\r\n" +
+		"  Crawl-delay: 2\r\n" +
+		"\r\n" +
+		"user-agent: *\r\n" +
+		"disallow: /\r\n" +
+		"\r\n" +
+		"Sitemap: http://www.example.com/a.xml\r\n" +
+		"Sitemap: http://www.example.com/b.xml"
+
+	if actual := d.String(); actual != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, actual)
+	}
+
+	robots, err := d.Compile(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if allowed, _ := robots.IsAllowed("Googlebot", "http://www.example.com/private/ok"); !allowed {
+		t.Errorf("Expected /private/ok to be allowed")
+	}
+
+	if allowed, _ := robots.IsAllowed("Googlebot", "http://www.example.com/tmp/"); allowed {
+		t.Errorf("Expected /tmp/ to be disallowed")
+	}
+
+	if allowed, _ := robots.IsAllowed("other", "http://www.example.com/private/ok"); allowed {
+		t.Errorf("Expected / to be disallowed for other")
+	}
+
+	if err := d.RemoveRule(5); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.RemoveRule(3); err != ErrNotRule {
+		t.Errorf("Expected ErrNotRule removing a user-agent line, got %v", err)
+	}
+
+	if _, err := d.AddRule(4, true, "/"); err != ErrNotGroup {
+		t.Errorf("Expected ErrNotGroup adding to a rule line, got %v", err)
+	}
+
+	var builderErr *BuilderError
+	if err := d.ReplaceRule(4, true, "relative"); !errors.As(err, &builderErr) {
+		t.Errorf("Expected a BuilderError for an invalid path, got %v", err)
+	}
+}
+
+func TestDocument_addGroupAndSetSitemaps(t *testing.T) {
+	d := ParseDocument("User-agent: a\n# no rules")
+
+	line, err := d.AddGroup("b", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.AddRule(line, false, "/b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.SetSitemaps("http://www.example.com/sitemap.xml"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "User-agent: a\n" +
+		"# no rules\n" +
+		"Disallow:\n" +
+		"\n" +
+		"User-agent: b\n" +
+		"User-agent: c\n" +
+		"Disallow: /b\n" +
+		"Sitemap: http://www.example.com/sitemap.xml"
+
+	if actual := d.String(); actual != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, actual)
+	}
+
+	robots, _ := d.Compile("http://www.example.com/robots.txt")
+	if groups := robots.Groups(); len(groups) != 2 || len(groups[0].Rules) != 0 {
+		t.Errorf("Expected a to keep its own empty group, got %+v", groups)
+	}
+
+	d = ParseDocument(documentContents)
+	if err := d.SetSitemaps("http://www.example.com/c.xml"); err != nil {
+		t.Fatal(err)
+	}
+
+	robots, _ = d.Compile("http://www.example.com/robots.txt")
+	if sitemaps := robots.Sitemaps(); len(sitemaps) != 1 || sitemaps[0] != "http://www.example.com/c.xml" {
+		t.Errorf("Expected only the c sitemap, got %v", sitemaps)
+	}
+
+	expected = documentContents[:strings.Index(documentContents, "Sitemap:")] +
+		"Sitemap: http://www.example.com/c.xml"
+
+	if actual := d.String(); actual != expected {
+		t.Errorf("Expected the first sitemap line to be reused, got %q", actual)
+	}
+
+	if _, err := d.AddGroup("Googlebot/2.1"); err == nil {
+		t.Errorf("Expected an error for an invalid user agent")
+	}
+}
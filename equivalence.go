@@ -0,0 +1,239 @@
+package robotstxt
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrTooComplex is returned when the wildcard rules of a group are too
+// complex to explore every path they can match
+var ErrTooComplex = errors.New("robotstxt: rules too complex to explore fully")
+
+// Equivalent returns true if a and b make the same decision for every
+// URL for the user agent. Rules are compared by what they match, not
+// how they are written, so "Disallow: /a" and "Disallow: /a*" are
+// equivalent.
+//
+// Equivalent returns ErrTooComplex if the wildcard rules are too complex
+// to compare every path and no difference was found.
+func Equivalent(a, b *RobotsTxt, userAgent string) (bool, error) {
+	_, aGroup := a.findGroup(userAgent)
+	_, bGroup := b.findGroup(userAgent)
+
+	equivalent, complete := equivalentGroups(aGroup, a.precedence, bGroup, b.precedence)
+	if !complete {
+		return false, ErrTooComplex
+	}
+
+	return equivalent, nil
+}
+
+// equivalentGroups returns true if the groups allow the same paths.
+// Either group may be nil if there is no group for the user agent. It
+// also returns false if the rules were too complex to explore fully
+// without finding a difference.
+func equivalentGroups(a *group, aPrecedence Precedence, b *group, bPrecedence Precedence) (bool, bool) {
+	if a == nil {
+		a = &group{}
+	}
+
+	if b == nil {
+		b = &group{}
+	}
+
+	equivalent := true
+	rules := append(append([]*rule{}, a.rules...), b.rules...)
+	complete := explorePaths(rules, func(path string) bool {
+		equivalent = a.isAllowed(path, aPrecedence) == b.isAllowed(path, bPrecedence)
+		return equivalent
+	})
+
+	return equivalent, complete
+}
+
+// Minimize returns a copy of the robots.txt file with every rule that
+// does not change a decision removed, such as duplicate rules, rules
+// shadowed by broader ones and allow rules in paths that are already
+// allowed. The copy has one group per user agent and makes the same
+// decisions as r.
+//
+// The copy has no directives or diagnostics as it no longer matches
+// the original file.
+//
+// If the wildcard rules of a group are too complex to explore every
+// path, the rules of the group that could not be checked are kept and
+// ErrTooComplex is returned with the copy.
+func (r *RobotsTxt) Minimize() (*RobotsTxt, error) {
+	var err error
+	minimized := *r
+	minimized.groups = make(map[string]*group, len(r.groups))
+	minimized.records = nil
+	minimized.directives = nil
+	minimized.diagnostics = nil
+
+	userAgents := make([]string, 0, len(r.groups))
+	for userAgent := range r.groups {
+		userAgents = append(userAgents, userAgent)
+	}
+	sort.Strings(userAgents)
+
+	for _, userAgent := range userAgents {
+		g := r.groups[userAgent]
+		rules, complete := minimizeRules(g, r.precedence)
+		if !complete {
+			err = ErrTooComplex
+		}
+
+		minimized.groups[userAgent] = &group{
+			rules:      rules,
+			crawlDelay: g.crawlDelay,
+			line:       g.line,
+		}
+
+		minimized.records = append(minimized.records, &record{
+			userAgents:    []userAgentLine{{name: userAgent, line: g.line}},
+			rules:         rules,
+			crawlDelay:    g.crawlDelay,
+			hasCrawlDelay: g.crawlDelay != 0,
+		})
+	}
+
+	return &minimized, err
+}
+
+// minimizeRules returns the rules of the group without the ones that
+// do not change any decision. Later rules are removed first so that
+// the first of any duplicates is kept. It returns false, with the rules
+// left so far, if they are too complex to explore fully.
+func minimizeRules(g *group, precedence Precedence) ([]*rule, bool) {
+	rules := g.rules
+
+	for {
+		needed, complete := neededRules(rules, precedence)
+		if !complete {
+			return rules, false
+		}
+
+		var unneeded []int
+		for i, rule := range rules {
+			if !needed[rule] {
+				unneeded = append(unneeded, i)
+			}
+		}
+
+		if len(unneeded) == 0 {
+			return rules, true
+		}
+
+		// Each of these rules can be removed on its own but not always
+		// together, such as both copies of a duplicate, so as many of
+		// the last ones as possible are removed at once
+		for n := len(unneeded); n > 0; n /= 2 {
+			without := withoutRules(rules, unneeded[len(unneeded)-n:])
+			if n == 1 {
+				rules = without
+				break
+			}
+
+			if equivalent, complete := equivalentGroups(&group{rules: rules}, precedence, &group{rules: without}, precedence); equivalent && complete {
+				rules = without
+				break
+			}
+		}
+	}
+}
+
+// withoutRules returns a copy of rules without the ones at the sorted
+// indexes
+func withoutRules(rules []*rule, indexes []int) []*rule {
+	without := make([]*rule, 0, len(rules)-len(indexes))
+	for i, rule := range rules {
+		if len(indexes) > 0 && indexes[0] == i {
+			indexes = indexes[1:]
+			continue
+		}
+
+		without = append(without, rule)
+	}
+
+	return without
+}
+
+// neededRules returns the rules that decide some path differently to
+// how it would be decided without them, so can not be removed. Any
+// other rule can be removed on its own without changing a decision. It
+// returns false if the rules were too complex to explore fully.
+func neededRules(rules []*rule, precedence Precedence) (map[*rule]bool, bool) {
+	needed := make(map[*rule]bool)
+
+	// Only the wildcard rules and the plain rules with a prefix of the
+	// path can match it, so only those are checked for each path
+	plain := make(map[string][]int)
+	var patterns []int
+	for i, rule := range rules {
+		if rule.pattern == nil {
+			plain[rule.path] = append(plain[rule.path], i)
+		} else {
+			patterns = append(patterns, i)
+		}
+	}
+
+	complete := explorePaths(rules, func(path string) bool {
+		candidates := ruleSubset{rules: rules, indexes: append([]int{}, patterns...)}
+		for n := 1; n <= len(path); n++ {
+			candidates.indexes = append(candidates.indexes, plain[path[:n]]...)
+		}
+		sort.Ints(candidates.indexes)
+
+		i, _ := matchRuleSet(candidates, path, precedence, nil)
+		if i < 0 || needed[candidates.rule(i)] {
+			return true
+		}
+
+		without := withoutRule{rules: candidates, skip: i}
+		j, _ := matchRuleSet(without, path, precedence, nil)
+		if allowed := j < 0 || without.ruleAllowed(j); allowed != candidates.ruleAllowed(i) {
+			needed[candidates.rule(i)] = true
+		}
+
+		return true
+	})
+
+	return needed, complete
+}
+
+// ruleSubset is a ruleSet of some of the rules in their original order
+type ruleSubset struct {
+	rules   []*rule
+	indexes []int
+}
+
+func (s ruleSubset) rule(i int) *rule { return s.rules[s.indexes[i]] }
+
+func (s ruleSubset) ruleCount() int                      { return len(s.indexes) }
+func (s ruleSubset) rulePath(i int) string               { return s.rule(i).path }
+func (s ruleSubset) ruleAllowed(i int) bool              { return s.rule(i).isAllowed }
+func (s ruleSubset) ruleWildcard(i int) bool             { return s.rule(i).pattern != nil }
+func (s ruleSubset) ruleMatches(i int, path string) bool { return s.rule(i).matches(path) }
+
+// withoutRule is a ruleSet with one of its rules left out
+type withoutRule struct {
+	rules ruleSet
+	skip  int
+}
+
+func (w withoutRule) index(i int) int {
+	if i >= w.skip {
+		return i + 1
+	}
+
+	return i
+}
+
+func (w withoutRule) ruleCount() int          { return w.rules.ruleCount() - 1 }
+func (w withoutRule) rulePath(i int) string   { return w.rules.rulePath(w.index(i)) }
+func (w withoutRule) ruleAllowed(i int) bool  { return w.rules.ruleAllowed(w.index(i)) }
+func (w withoutRule) ruleWildcard(i int) bool { return w.rules.ruleWildcard(w.index(i)) }
+func (w withoutRule) ruleMatches(i int, path string) bool {
+	return w.rules.ruleMatches(w.index(i), path)
+}
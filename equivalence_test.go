@@ -0,0 +1,196 @@
+package robotstxt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEquivalent(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	tests := []struct {
+		a, b       string
+		equivalent bool
+	}{
+		{"Disallow: /a", "Disallow: /a*", true},
+		{"Disallow: /a", "Disallow: /a\nDisallow: /a/b", true},
+		{"Disallow: /a", "Disallow: /a\nAllow: /b", true},
+		{"Disallow: /a$", "Disallow: /a*$", false},
+		{"Disallow: /*.pdf$", "Disallow: /*.pdf", false},
+		{"Disallow: /a\nAllow: /a", "", true},
+		{"Disallow: /a\nAllow: /a/", "Disallow: /a", false},
+		{"Disallow: /", "Disallow: /*", true},
+		{"Disallow: /a%2A", "Disallow: /a*", false},
+	}
+
+	for _, test := range tests {
+		a, _ := Parse("User-agent: *\n"+test.a, url)
+		b, _ := Parse("User-agent: *\n"+test.b, url)
+
+		if actual, err := Equivalent(a, b, "bot"); err != nil || actual != test.equivalent {
+			t.Errorf("Expected %q and %q equivalent to be %v, got %v, %v", test.a, test.b, test.equivalent, actual, err)
+		}
+	}
+}
+
+func TestEquivalent_groupsPerUserAgent(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	a, _ := Parse("User-agent: *\nDisallow: /\n", url)
+	b, _ := Parse("User-agent: *\nDisallow: /\n\nUser-agent: b\nDisallow:\n", url)
+
+	if equivalent, _ := Equivalent(a, b, "a"); !equivalent {
+		t.Errorf("Expected a to be equivalent")
+	}
+
+	if equivalent, _ := Equivalent(a, b, "b"); equivalent {
+		t.Errorf("Expected b not to be equivalent")
+	}
+}
+
+func TestEquivalent_largeFiles(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := largeRobotsTxt(5000)
+	a, _ := Parse(contents, url)
+	b, _ := Parse(contents, url)
+	c, _ := Parse(strings.Replace(contents, "Disallow: /section-4000/private/\n", "Disallow: /section-4000/\n", 1), url)
+
+	if equivalent, err := Equivalent(a, b, "*"); !equivalent || err != nil {
+		t.Errorf("Expected the same file to be equivalent, got %v, %v", equivalent, err)
+	}
+
+	if equivalent, err := Equivalent(a, c, "*"); equivalent || err != nil {
+		t.Errorf("Expected a changed file not to be equivalent, got %v, %v", equivalent, err)
+	}
+}
+
+func TestEquivalent_tooComplex(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("User-agent: *\n")
+	for i := 0; i < 24; i++ {
+		fmt.Fprintf(&sb, "Disallow: /*%c*%c$\n", 'a'+i, 'A'+i)
+	}
+
+	robots, _ := Parse(sb.String(), "http://www.example.com/robots.txt")
+
+	if equivalent, err := Equivalent(robots, robots, "*"); equivalent || err != ErrTooComplex {
+		t.Errorf("Expected ErrTooComplex, got %v, %v", equivalent, err)
+	}
+
+	minimized, err := robots.Minimize()
+	if err != ErrTooComplex {
+		t.Errorf("Expected ErrTooComplex, got %v", err)
+	}
+
+	if rules := minimized.Rules("*"); len(rules) != 24 {
+		t.Errorf("Expected every rule to be kept, got %d", len(rules))
+	}
+}
+
+func TestRobotsTxt_minimize(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	robots, _ := Parse(`
+		User-agent: *
+		Disallow: /private/
+		Disallow: /private/
+		Disallow: /private/secret/
+		Allow: /public/
+		Allow: /private/ok
+		Disallow: /tmp*
+		Disallow: /tmp/
+
+		User-agent: a
+		Allow: /
+	`, url)
+
+	minimized, err := robots.Minimize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "User-agent: *\n" +
+		"Disallow: /private/\n" +
+		"Allow: /private/ok\n" +
+		"Disallow: /tmp*\n" +
+		"\n" +
+		"User-agent: a\n" +
+		"Disallow:\n"
+
+	if actual := minimized.String(); actual != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, actual)
+	}
+
+	for _, userAgent := range []string{"*", "a", "b"} {
+		if equivalent, err := Equivalent(robots, minimized, userAgent); !equivalent || err != nil {
+			t.Errorf("Expected the minimized file to be equivalent for %q", userAgent)
+		}
+	}
+
+	if groups := minimized.Groups(); len(groups) != 2 || len(groups[0].Rules) != 3 {
+		t.Errorf("Expected the groups to match the minimized rules, got %+v", groups)
+	}
+}
+
+func TestRobotsTxt_minimizeLegacyOrder(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := strings.Join([]string{
+		"User-agent: *",
+		"Disallow: /fish*.php",
+		"Allow: /fish*",
+		"Allow: /fish*.php",
+	}, "\n")
+
+	robots, _ := Parse(contents, url, WithPrecedence(LegacyOrder))
+	minimized, err := robots.Minimize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rules := minimized.Rules("*"); len(rules) != 1 || rules[0].Path != "/fish*.php" {
+		t.Errorf("Expected only the first pattern to be kept, got %+v", rules)
+	}
+
+	if equivalent, err := Equivalent(robots, minimized, "*"); !equivalent || err != nil {
+		t.Errorf("Expected the minimized file to be equivalent")
+	}
+}
+
+// redundantRobotsTxt returns a robots.txt file with n disallowed
+// directories and a redundant rule in every tenth one
+func redundantRobotsTxt(n int) string {
+	var sb strings.Builder
+	sb.WriteString("User-agent: *\n")
+
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "Disallow: /p%d/\n", i)
+		if i%10 == 0 {
+			fmt.Fprintf(&sb, "Disallow: /p%d/private/\n", i)
+		}
+	}
+
+	return sb.String()
+}
+
+func TestRobotsTxt_minimizeLargeGroup(t *testing.T) {
+	robots, _ := Parse(redundantRobotsTxt(5000)+"Disallow: /*.pdf$\n", "http://www.example.com/robots.txt")
+	minimized, err := robots.Minimize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rules := minimized.Rules("*"); len(rules) != 5001 {
+		t.Errorf("Expected 5001 rules, got %d", len(rules))
+	}
+
+	if equivalent, err := Equivalent(robots, minimized, "*"); !equivalent || err != nil {
+		t.Errorf("Expected the minimized file to be equivalent")
+	}
+}
+
+func BenchmarkMinimize(b *testing.B) {
+	robots, _ := Parse(redundantRobotsTxt(200), "http://www.example.com/robots.txt")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		robots.Minimize()
+	}
+}
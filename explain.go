@@ -0,0 +1,123 @@
+package robotstxt
+
+// Reason is why a rule was chosen to decide if a URL is allowed
+type Reason int
+
+const (
+	// ReasonNoGroup means there is no group for the user agent and
+	// no * group so everything is allowed
+	ReasonNoGroup Reason = iota
+
+	// ReasonNoMatch means no rule in the group matched so the
+	// URL is allowed
+	ReasonNoMatch
+
+	// ReasonLongestMatch means the rule was the longest matching rule
+	ReasonLongestMatch
+
+	// ReasonAllowWinsTie means an allow and disallow rule were the
+	// longest matching rules and the allow rule won
+	ReasonAllowWinsTie
+
+	// ReasonFirstPattern means the rule was the first matching wildcard
+	// rule, which takes precedence when using LegacyOrder
+	ReasonFirstPattern
+)
+
+func (r Reason) String() string {
+	switch r {
+	case ReasonNoGroup:
+		return "no matching group"
+	case ReasonNoMatch:
+		return "no matching rule"
+	case ReasonLongestMatch:
+		return "longest matching rule"
+	case ReasonAllowWinsTie:
+		return "allow wins equal length rules"
+	case ReasonFirstPattern:
+		return "first matching wildcard rule"
+	}
+
+	return "unknown"
+}
+
+// MatchedRule is a rule that matched a URL
+type MatchedRule struct {
+	// Line is the 1-based line number of the rule
+	Line int
+	// Text is the line the rule came from
+	Text string
+	// Path is the path or pattern the rule matches, after normalisation
+	Path string
+	// Allowed is true for allow rules and false for disallow rules
+	Allowed bool
+}
+
+// Explanation describes how IsAllowed decided if a URL is allowed
+type Explanation struct {
+	// Allowed is the same result IsAllowed returns
+	Allowed bool
+	// UserAgent is the name of the group that was used, empty if
+	// there was none
+	UserAgent string
+	// UserAgentLine is the 1-based line number of the first user-agent
+	// line for the group or 0 if there was no group
+	UserAgentLine int
+	// Rule is the rule that decided the result or nil if no rule matched
+	Rule *MatchedRule
+	// OtherMatches are the other rules that matched the URL
+	OtherMatches []MatchedRule
+	// Reason is why Rule was chosen
+	Reason Reason
+}
+
+// Explain is like IsAllowed but returns the group and rules behind
+// the decision
+func (r *RobotsTxt) Explain(userAgent string, urlStr string) (*Explanation, error) {
+	u, err := parseAndNormalizeURL(urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != r.url.Scheme || u.Host != r.url.Host {
+		return nil, &InvalidHostError{}
+	}
+
+	explanation := &Explanation{
+		Allowed: true,
+		Reason:  ReasonNoGroup,
+	}
+
+	name, group := r.findGroup(userAgent)
+	if group == nil {
+		return explanation, nil
+	}
+
+	explanation.UserAgent = name
+	explanation.UserAgentLine = group.line
+
+	var matches []*rule
+	winner, reason := group.match(matchPath(u), r.precedence, func(rule *rule) {
+		matches = append(matches, rule)
+	})
+
+	explanation.Reason = reason
+
+	for _, rule := range matches {
+		matched := MatchedRule{
+			Line:    rule.line,
+			Text:    rule.text,
+			Path:    rule.path,
+			Allowed: rule.isAllowed,
+		}
+
+		if rule == winner {
+			explanation.Rule = &matched
+			explanation.Allowed = rule.isAllowed
+		} else {
+			explanation.OtherMatches = append(explanation.OtherMatches, matched)
+		}
+	}
+
+	return explanation, nil
+}
@@ -0,0 +1,90 @@
+package robotstxt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRobotsTxt_explainDecisions(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := "User-agent: *\n" +
+		"Disallow: /\n" +
+		"\n" +
+		"User-agent: a\n" +
+		"User-agent: b\n" +
+		"Disallow: /fish*.php # no fish\n" +
+		"Allow: /fish/index.php\n" +
+		"Allow: /test\n" +
+		"Disallow: /test\n"
+
+	robots, _ := Parse(contents, url)
+
+	explanation, err := robots.Explain("b", "http://www.example.com/fish/index.php")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Explanation{
+		Allowed:       true,
+		UserAgent:     "b",
+		UserAgentLine: 5,
+		Rule: &MatchedRule{
+			Line:    7,
+			Text:    "Allow: /fish/index.php",
+			Path:    "/fish/index.php",
+			Allowed: true,
+		},
+		OtherMatches: []MatchedRule{
+			{Line: 6, Text: "Disallow: /fish*.php # no fish", Path: "/fish*.php", Allowed: false},
+		},
+		Reason: ReasonLongestMatch,
+	}
+
+	if !reflect.DeepEqual(explanation, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, explanation)
+	}
+
+	explanation, _ = robots.Explain("a", "http://www.example.com/test/page")
+	if !explanation.Allowed || explanation.Reason != ReasonAllowWinsTie || explanation.Rule.Line != 8 {
+		t.Errorf("Expected allow to win the tie, got %+v", explanation)
+	}
+
+	explanation, _ = robots.Explain("a", "http://www.example.com/other")
+	if !explanation.Allowed || explanation.Reason != ReasonNoMatch || explanation.Rule != nil {
+		t.Errorf("Expected no rule to match, got %+v", explanation)
+	}
+
+	explanation, _ = robots.Explain("c", "http://www.example.com/other")
+	if explanation.Allowed || explanation.UserAgent != "*" || explanation.UserAgentLine != 1 {
+		t.Errorf("Expected the * group to disallow, got %+v", explanation)
+	}
+}
+
+func TestRobotsTxt_explainLegacyOrder(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := `
+		User-agent: *
+		Disallow: /fish*.php
+		Allow: /fish/index.php
+	`
+
+	robots, _ := Parse(contents, url, WithPrecedence(LegacyOrder))
+
+	explanation, _ := robots.Explain("*", "http://www.example.com/fish/index.php")
+	if explanation.Allowed || explanation.Reason != ReasonFirstPattern || len(explanation.OtherMatches) != 1 {
+		t.Errorf("Expected the first pattern to win, got %+v", explanation)
+	}
+}
+
+func TestRobotsTxt_explainWithoutGroups(t *testing.T) {
+	robots, _ := Parse("", "http://www.example.com/robots.txt")
+
+	explanation, _ := robots.Explain("a", "http://www.example.com/")
+	if !explanation.Allowed || explanation.Reason != ReasonNoGroup {
+		t.Errorf("Expected no group, got %+v", explanation)
+	}
+
+	if _, err := robots.Explain("a", "http://example.com/"); err == nil {
+		t.Errorf("Expected an error for a URL on another host")
+	}
+}
@@ -0,0 +1,232 @@
+package robotstxt
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// MaxRedirects is the number of consecutive redirects Fetch will follow
+// before treating the robots.txt file as unavailable
+const MaxRedirects = 5
+
+// FetchStatus is which of the RFC 9309 access results applied
+// when fetching a robots.txt file
+type FetchStatus int
+
+const (
+	// StatusParsed means the server returned a 2xx response and
+	// the body was parsed
+	StatusParsed FetchStatus = iota
+
+	// StatusUnavailable means the server returned a 4xx response, other
+	// than 429, or too many redirects so crawling is allowed everywhere
+	StatusUnavailable
+
+	// StatusUnreachable means the server returned a 5xx or 429 response
+	// or could not be reached so crawling is disallowed everywhere
+	StatusUnreachable
+)
+
+func (s FetchStatus) String() string {
+	switch s {
+	case StatusParsed:
+		return "parsed"
+	case StatusUnavailable:
+		return "unavailable"
+	case StatusUnreachable:
+		return "unreachable"
+	}
+
+	return "unknown"
+}
+
+// FetchInfo describes how a robots.txt file was fetched
+type FetchInfo struct {
+	// Status is which of the RFC 9309 cases applied
+	Status FetchStatus
+	// StatusCode is the HTTP status code of the final response or
+	// 0 if there was no response
+	StatusCode int
+	// URL is the URL the robots.txt file was fetched from after
+	// following any redirects
+	URL string
+	// Header is the header of the final response or nil if
+	// there was no response
+	Header http.Header
+	// FetchedAt is when the robots.txt file was fetched
+	FetchedAt time.Time
+	// Err is the network error for unreachable files, if any
+	Err error
+}
+
+var errTooManyRedirects = errors.New("robotstxt: too many redirects")
+
+// RobotsURL returns the URL of the robots.txt file for the site
+// the URL belongs to
+func RobotsURL(siteURL string) (string, error) {
+	u, err := parseAndNormalizeURL(siteURL)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return "", &url.Error{Op: "parse", URL: siteURL, Err: errors.New("URL must be absolute")}
+	}
+
+	robotsURL := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+
+	return robotsURL.String(), nil
+}
+
+// Fetch downloads and parses the robots.txt file for the site siteURL
+// belongs to, following the RFC 9309 rules for HTTP status codes:
+//
+//   - 2xx responses are parsed
+//   - 4xx responses allow crawling everywhere
+//   - 5xx and 429 responses and network errors disallow crawling everywhere
+//
+// Up to MaxRedirects redirects are followed. Use FetchInfo on the result to
+// find out which case applied. An error is only returned if siteURL is
+// invalid or ctx is done.
+//
+// If client is nil http.DefaultClient is used.
+func Fetch(ctx context.Context, client *http.Client, siteURL string, opts ...Option) (*RobotsTxt, error) {
+	robotsURL, err := RobotsURL(siteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	entry, err := fetchEntry(ctx, client, robotsURL, o.maxBytes)
+	if entry == nil {
+		return nil, err
+	}
+
+	robots, parseErr := entry.Parse(opts...)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	robots.fetch.Err = err
+
+	return robots, nil
+}
+
+// fetchEntry downloads the robots.txt file at robotsURL. If the server
+// could not be reached or the connection failed while reading the body
+// the entry has a StatusCode of 0 and the network error is also
+// returned. The entry is nil if ctx is done.
+func fetchEntry(ctx context.Context, client *http.Client, robotsURL string, maxBytes int64) (*Entry, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{
+		URL:       robotsURL,
+		FinalURL:  robotsURL,
+		FetchedAt: time.Now(),
+	}
+
+	resp, err := limitRedirects(client).Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		// Too many redirects returns the last redirect response, with
+		// its body closed, which is treated the same as a 4xx
+		if resp != nil && errors.Is(err, errTooManyRedirects) {
+			entry.FinalURL = resp.Request.URL.String()
+			entry.StatusCode = resp.StatusCode
+			entry.Header = resp.Header
+		}
+
+		return entry, err
+	}
+	defer resp.Body.Close()
+
+	entry.FinalURL = resp.Request.URL.String()
+	entry.StatusCode = resp.StatusCode
+	entry.Header = resp.Header
+
+	if statusFor(resp.StatusCode) != StatusParsed {
+		// Drain so the connection can be reused
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxBytes))
+		return entry, nil
+	}
+
+	// Read one byte past the limit so Parse can detect truncation
+	entry.Body, err = io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		// A partial body is treated the same as no response
+		entry.StatusCode = 0
+		entry.Header = nil
+		entry.Body = nil
+
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+func statusFor(statusCode int) FetchStatus {
+	switch {
+	case statusCode == 0:
+		return StatusUnreachable
+	case statusCode >= 200 && statusCode < 300:
+		return StatusParsed
+	case statusCode == http.StatusTooManyRequests:
+		return StatusUnreachable
+	case statusCode >= 500:
+		return StatusUnreachable
+	}
+
+	// Remaining 3xx responses are redirects that could not be
+	// followed so are treated the same as 4xx
+	return StatusUnavailable
+}
+
+// limitRedirects returns a copy of client that stops after MaxRedirects
+func limitRedirects(client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	limited := *client
+	limited.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > MaxRedirects {
+			return errTooManyRedirects
+		}
+
+		if client.CheckRedirect != nil {
+			return client.CheckRedirect(req, via)
+		}
+
+		return nil
+	}
+
+	return &limited
+}
+
+// FetchInfo returns how the robots.txt file was fetched or nil if
+// it was not created by Fetch
+func (r *RobotsTxt) FetchInfo() *FetchInfo {
+	return r.fetch
+}
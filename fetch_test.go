@@ -0,0 +1,188 @@
+package robotstxt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetch_applyStatusCodeRules(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		status     FetchStatus
+		allowed    bool
+	}{
+		{http.StatusOK, StatusParsed, false},
+		{http.StatusNotFound, StatusUnavailable, true},
+		{http.StatusForbidden, StatusUnavailable, true},
+		{http.StatusGone, StatusUnavailable, true},
+		{http.StatusTooManyRequests, StatusUnreachable, false},
+		{http.StatusInternalServerError, StatusUnreachable, false},
+		{http.StatusServiceUnavailable, StatusUnreachable, false},
+	}
+
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.statusCode)
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private")
+		}))
+
+		robots, err := Fetch(context.Background(), server.Client(), server.URL+"/some/page")
+		server.Close()
+		if err != nil {
+			t.Errorf("Unexpected error for %d: %v", test.statusCode, err)
+			continue
+		}
+
+		info := robots.FetchInfo()
+		if info.Status != test.status || info.StatusCode != test.statusCode {
+			t.Errorf("Expected %v for %d, got %v", test.status, test.statusCode, info.Status)
+		}
+
+		allowed, err := robots.IsAllowed("bot", server.URL+"/private")
+		if err != nil {
+			t.Error(err)
+		} else if allowed != test.allowed {
+			t.Errorf("Expected /private allowed to be %v for %d", test.allowed, test.statusCode)
+		}
+	}
+}
+
+func TestFetch_requestRobotsTxtFromTheSiteRoot(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	}))
+	defer server.Close()
+
+	Fetch(context.Background(), server.Client(), server.URL+"/a/b?c=d")
+
+	if path != "/robots.txt" {
+		t.Errorf("Expected /robots.txt to be requested, got %s", path)
+	}
+}
+
+func TestFetch_followUpToFiveRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(r.URL.Query().Get("n"), "%d", &n)
+
+		if n < MaxRedirects {
+			http.Redirect(w, r, fmt.Sprintf("/robots.txt?n=%d", n+1), http.StatusMovedPermanently)
+			return
+		}
+
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private")
+	}))
+	defer server.Close()
+
+	robots, err := Fetch(context.Background(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := robots.FetchInfo()
+	if info.Status != StatusParsed {
+		t.Errorf("Expected robots.txt to be parsed, got %v", info.Status)
+	}
+
+	if info.URL != server.URL+"/robots.txt?n=5" {
+		t.Errorf("Expected final URL to be recorded, got %s", info.URL)
+	}
+
+	allowed, _ := robots.IsAllowed("bot", server.URL+"/private")
+	if allowed {
+		t.Errorf("The path /private should be disallowed")
+	}
+}
+
+func TestFetch_treatTooManyRedirectsAsUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/robots.txt", http.StatusFound)
+	}))
+	defer server.Close()
+
+	robots, err := Fetch(context.Background(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if robots.FetchInfo().Status != StatusUnavailable {
+		t.Errorf("Expected robots.txt to be unavailable, got %v", robots.FetchInfo().Status)
+	}
+
+	allowed, _ := robots.IsAllowed("bot", server.URL+"/private")
+	if !allowed {
+		t.Errorf("The path /private should be allowed")
+	}
+}
+
+func TestFetch_treatNetworkErrorsAsUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	siteURL := server.URL
+	server.Close()
+
+	robots, err := Fetch(context.Background(), nil, siteURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := robots.FetchInfo()
+	if info.Status != StatusUnreachable || info.Err == nil {
+		t.Errorf("Expected robots.txt to be unreachable with an error, got %v", info)
+	}
+
+	allowed, _ := robots.IsAllowed("bot", siteURL+"/")
+	if allowed {
+		t.Errorf("The path / should be disallowed")
+	}
+}
+
+func TestFetch_treatBodyReadErrorsAsUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		fmt.Fprint(w, "User-agent: *\n")
+		w.(http.Flusher).Flush()
+
+		// Close the connection before the rest of the body is sent
+		panic(http.ErrAbortHandler)
+	}))
+	defer server.Close()
+
+	robots, err := Fetch(context.Background(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := robots.FetchInfo()
+	if info.Status != StatusUnreachable || info.Err == nil {
+		t.Errorf("Expected robots.txt to be unreachable with an error, got %v", info)
+	}
+
+	allowed, _ := robots.IsAllowed("bot", server.URL+"/")
+	if allowed {
+		t.Errorf("The path / should be disallowed")
+	}
+}
+
+func TestFetch_returnErrorWhenContextIsDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Fetch(ctx, server.Client(), server.URL)
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestFetch_returnErrorForRelativeUrls(t *testing.T) {
+	_, err := Fetch(context.Background(), nil, "/robots.txt")
+	if err == nil {
+		t.Errorf("Expected an error for a relative URL")
+	}
+}
@@ -0,0 +1,154 @@
+package robotstxt
+
+import "strings"
+
+// glob matches paths against a wildcard rule. A path matches if it
+// starts with the segments in order, with any characters in place of
+// the * between them, and if the glob is anchored there is nothing
+// after the last segment.
+//
+// Matching takes time linear in the length of the path and the glob,
+// however many * there are, and does not allocate.
+type glob struct {
+	segments []string
+	// tables are the KMP failure tables of the segments that need to
+	// be searched for, nil for the others
+	tables   [][]int32
+	anchored bool
+}
+
+// compileGlob compiles the unescaped path of a wildcard rule. A trailing
+// $ anchors the glob, a trailing %24 is a literal $ and %2A is a
+// literal *.
+func compileGlob(path string) *glob {
+	anchored := strings.HasSuffix(path, "$")
+	switch {
+	case anchored:
+		path = path[:len(path)-1]
+	case strings.HasSuffix(path, "%24"):
+		path = path[:len(path)-3] + "$"
+	case strings.HasSuffix(path, "%2524"):
+		path = path[:len(path)-5] + "%24"
+	}
+
+	segments := strings.Split(path, "*")
+	for i, segment := range segments {
+		segments[i] = strings.Replace(segment, "%2A", "*", -1)
+	}
+
+	g := &glob{
+		segments: segments,
+		tables:   make([][]int32, len(segments)),
+		anchored: anchored,
+	}
+
+	// The first segment is matched as a prefix and the last of an
+	// anchored glob as a suffix so only the others are searched for
+	for i := 1; i < len(segments); i++ {
+		if len(segments[i]) > 1 && (i < len(segments)-1 || !anchored) {
+			g.tables[i] = kmpTable(segments[i])
+		}
+	}
+
+	return g
+}
+
+// match checks if the path matches the glob
+func (g *glob) match(path string) bool {
+	if !strings.HasPrefix(path, g.segments[0]) {
+		return false
+	}
+
+	last := len(g.segments) - 1
+	if last == 0 {
+		return !g.anchored || len(path) == len(g.segments[0])
+	}
+
+	// Matching each segment at its first possible position leaves the
+	// most room for the rest so there is no need to backtrack
+	pos := len(g.segments[0])
+	for i := 1; i < last; i++ {
+		index := g.index(i, path, pos)
+		if index < 0 {
+			return false
+		}
+
+		pos = index + len(g.segments[i])
+	}
+
+	if g.anchored {
+		return len(path)-len(g.segments[last]) >= pos && strings.HasSuffix(path, g.segments[last])
+	}
+
+	return g.index(last, path, pos) > -1
+}
+
+// index returns the index of the first occurrence of segment i in path
+// at or after start, or -1 if there is none
+func (g *glob) index(i int, path string, start int) int {
+	segment := g.segments[i]
+
+	switch len(segment) {
+	case 0:
+		return start
+	case 1:
+		if index := strings.IndexByte(path[start:], segment[0]); index > -1 {
+			return start + index
+		}
+
+		return -1
+	}
+
+	return kmpIndex(path, segment, g.tables[i], start)
+}
+
+// kmpTable returns the Knuth-Morris-Pratt failure table for s: the
+// length of the longest proper prefix of s[:i+1] that is also a suffix
+func kmpTable(s string) []int32 {
+	table := make([]int32, len(s))
+
+	k := 0
+	for i := 1; i < len(s); i++ {
+		for k > 0 && s[i] != s[k] {
+			k = int(table[k-1])
+		}
+
+		if s[i] == s[k] {
+			k++
+		}
+
+		table[i] = int32(k)
+	}
+
+	return table
+}
+
+// kmpIndex returns the index of the first occurrence of s in text at or
+// after start, or -1 if there is none
+func kmpIndex(text, s string, table []int32, start int) int {
+	k := 0
+	for i := start; i < len(text); i++ {
+		if k == 0 {
+			// Skip straight to the next possible start
+			index := strings.IndexByte(text[i:], s[0])
+			if index < 0 {
+				return -1
+			}
+
+			i += index
+		}
+
+		for k > 0 && text[i] != s[k] {
+			k = int(table[k-1])
+		}
+
+		if text[i] == s[k] {
+			k++
+			if k == len(s) {
+				return i - len(s) + 1
+			}
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,141 @@
+package robotstxt
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// compileRegexp is how wildcard rules were matched before glob and
+// is kept to check glob makes the same decisions
+func compileRegexp(pattern string) *regexp.Regexp {
+	pattern = regexp.QuoteMeta(pattern)
+	pattern = strings.Replace(pattern, "\\*", "(?:.*)", -1)
+
+	pattern = replaceSuffix(pattern, "\\$", "$")
+	pattern = replaceSuffix(pattern, "%24", "\\$")
+	pattern = replaceSuffix(pattern, "%2524", "%24")
+
+	pattern = strings.Replace(pattern, "%2A", "\\*", -1)
+
+	return regexp.MustCompile("^" + pattern)
+}
+
+func randomString(rnd *rand.Rand, alphabet string, prefix string, maxLength int) string {
+	b := []byte(prefix)
+	for i := rnd.Intn(maxLength + 1); i > 0; i-- {
+		b = append(b, alphabet[rnd.Intn(len(alphabet))])
+	}
+
+	return string(b)
+}
+
+func TestGlob_sameDecisionsAsRegexp(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	// Regular expressions do not match \n with . so it is left out
+	// as glob matches any character with *
+	patternAlphabet := "/ab*$%2A4."
+	pathAlphabet := "/ab*$%2A4.?"
+
+	for i := 0; i < 3000; i++ {
+		pattern := randomString(rnd, patternAlphabet, "/", 10)
+		if !isPattern(pattern) {
+			pattern += "*"
+		}
+
+		g := compileGlob(pattern)
+		re := compileRegexp(pattern)
+
+		for j := 0; j < 200; j++ {
+			path := randomString(rnd, pathAlphabet, "/", 12)
+			if expected, actual := re.MatchString(path), g.match(path); expected != actual {
+				t.Fatalf("Expected %q matching %q to be %v, got %v", pattern, path, expected, actual)
+			}
+		}
+	}
+}
+
+func TestGlob_match(t *testing.T) {
+	tests := []struct {
+		pattern string
+		matches []string
+		misses  []string
+	}{
+		{"/fish*.php", []string{"/fish.php", "/fishheads/catfish.php?parameters"}, []string{"/Fish.PHP"}},
+		{"/*.php$", []string{"/filename.php", "/folder/filename.php"}, []string{"/filename.php?parameters", "/filename.php/", "/windows.PHP"}},
+		{"/fish*.php$", []string{"/fish.php", "/fishheads/catfish.php"}, []string{"/fish.php?x", "/fish.phpx"}},
+		{"/a*ab*abc*abcd$", []string{"/aababcabcd", "/aaaaababcxabcabcd"}, []string{"/abcdabcabaa", "/aababcabc"}},
+		{"/price%24", []string{"/price$", "/price$/x"}, []string{"/price"}},
+		{"/a%2A*", []string{"/a*", "/a*b"}, []string{"/ab"}},
+		{"*", []string{"/", "/anything"}, nil},
+		{"/$", []string{"/"}, []string{"/a"}},
+	}
+
+	for _, test := range tests {
+		g := compileGlob(test.pattern)
+
+		for _, path := range test.matches {
+			if !g.match(path) {
+				t.Errorf("Expected %q to match %q", test.pattern, path)
+			}
+		}
+
+		for _, path := range test.misses {
+			if g.match(path) {
+				t.Errorf("Expected %q not to match %q", test.pattern, path)
+			}
+		}
+	}
+}
+
+func TestGlob_matchDoesNotAllocate(t *testing.T) {
+	g := compileGlob("/*abcabd*x*.php$")
+	path := "/" + strings.Repeat("abcab", 100) + "abd/x/file.php"
+
+	allocs := testing.AllocsPerRun(100, func() {
+		g.match(path)
+	})
+
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
+
+var globBenchmarks = []struct {
+	name    string
+	pattern string
+	path    string
+}{
+	{"Suffix", "/*.php$", "/folder/subfolder/filename.php"},
+	{"Middle", "/fish*.php", "/fishheads/catfish/and/more/fish.php?parameters=1"},
+	{"ManyStars", "/" + strings.Repeat("a*", 200) + "b", "/" + strings.Repeat("a", 400)},
+	{"LongPath", "/*needle*", "/" + strings.Repeat("haystack/", 1000) + "needle"},
+}
+
+func BenchmarkGlob(b *testing.B) {
+	for _, bm := range globBenchmarks {
+		g := compileGlob(bm.pattern)
+
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				g.match(bm.path)
+			}
+		})
+	}
+}
+
+func BenchmarkRegexp(b *testing.B) {
+	for _, bm := range globBenchmarks {
+		re := compileRegexp(bm.pattern)
+
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				re.MatchString(bm.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package robotstxt
+
+import "strings"
+
+// minIndexedRules is the number of plain rules a group needs before
+// they are indexed. Scanning fewer rules is as fast as the index.
+const minIndexedRules = 16
+
+// ruleIndex finds the rules that decide a path in time proportional to
+// the length of the path, however many plain rules there are. Plain
+// rules are stored in a radix trie by path and wildcard rules are still
+// checked one by one.
+type ruleIndex struct {
+	root     trieNode
+	patterns []int
+}
+
+// trieNode is a node of a radix trie of rule paths. The path of a node
+// is the prefixes of it and its parents joined together.
+type trieNode struct {
+	prefix string
+	// labels are the first bytes of the prefixes of the children in
+	// the same order as children, sorted
+	labels   []byte
+	children []*trieNode
+	// firstAllow, firstDisallow and last are the indexes of the rules
+	// with the path of the node or -1 if there are none
+	firstAllow, firstDisallow, last int
+}
+
+// ruleIndex returns the index of the group's rules, building it on
+// first use, or nil if the group has too few plain rules to need one.
+// Rules must not be added to the group after it has been used.
+func (r *group) ruleIndex() *ruleIndex {
+	r.indexOnce.Do(func() {
+		plain := 0
+		for _, rule := range r.rules {
+			if rule.pattern == nil {
+				plain++
+			}
+		}
+
+		if plain >= minIndexedRules {
+			r.index = newRuleIndex(r)
+		}
+	})
+
+	return r.index
+}
+
+func newRuleIndex(rules ruleSet) *ruleIndex {
+	index := &ruleIndex{root: newTrieNode("")}
+
+	for i, n := 0, rules.ruleCount(); i < n; i++ {
+		if rules.ruleWildcard(i) {
+			index.patterns = append(index.patterns, i)
+		} else {
+			index.insert(rules.rulePath(i), i, rules.ruleAllowed(i))
+		}
+	}
+
+	return index
+}
+
+func newTrieNode(prefix string) trieNode {
+	return trieNode{prefix: prefix, firstAllow: -1, firstDisallow: -1, last: -1}
+}
+
+// insert adds the rule at index i of the group's rules
+func (idx *ruleIndex) insert(path string, i int, isAllowed bool) {
+	n := &idx.root
+
+	for path != "" {
+		j, ok := n.child(path[0])
+		if !ok {
+			child := newTrieNode(path)
+			n.labels = append(n.labels, 0)
+			n.children = append(n.children, nil)
+			copy(n.labels[j+1:], n.labels[j:])
+			copy(n.children[j+1:], n.children[j:])
+			n.labels[j] = path[0]
+			n.children[j] = &child
+			n = &child
+			break
+		}
+
+		child := n.children[j]
+		common := commonPrefixLength(child.prefix, path)
+
+		// Split the child if the path only shares part of its prefix
+		if common < len(child.prefix) {
+			split := newTrieNode(child.prefix[:common])
+			split.labels = []byte{child.prefix[common]}
+			split.children = []*trieNode{child}
+			child.prefix = child.prefix[common:]
+			n.children[j] = &split
+			child = &split
+		}
+
+		n = child
+		path = path[common:]
+	}
+
+	if isAllowed && n.firstAllow < 0 {
+		n.firstAllow = i
+	}
+
+	if !isAllowed && n.firstDisallow < 0 {
+		n.firstDisallow = i
+	}
+
+	n.last = i
+}
+
+// child returns the index of the child whose prefix starts with c or,
+// if there is none, the index it would be inserted at
+func (n *trieNode) child(c byte) (int, bool) {
+	lo, hi := 0, len(n.labels)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if n.labels[mid] < c {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	return lo, lo < len(n.labels) && n.labels[lo] == c
+}
+
+// longestPrefix returns the node of the longest rule path that is a
+// prefix of path or nil if there is none
+func (idx *ruleIndex) longestPrefix(path string) *trieNode {
+	var longest *trieNode
+
+	n := &idx.root
+	for {
+		if n.last > -1 {
+			longest = n
+		}
+
+		if path == "" {
+			return longest
+		}
+
+		j, ok := n.child(path[0])
+		if !ok || !strings.HasPrefix(path, n.children[j].prefix) {
+			return longest
+		}
+
+		n = n.children[j]
+		path = path[len(n.prefix):]
+	}
+}
+
+// match is the same as matchRuleSet without reporting every matching
+// rule. rules must be the rules the index was built from.
+func (idx *ruleIndex) match(rules ruleSet, path string, precedence Precedence) (int, Reason) {
+	if precedence == LegacyOrder {
+		for _, i := range idx.patterns {
+			if rules.ruleMatches(i, path) {
+				return i, ReasonFirstPattern
+			}
+		}
+
+		if n := idx.longestPrefix(path); n != nil {
+			return n.last, ReasonLongestMatch
+		}
+
+		return -1, ReasonNoMatch
+	}
+
+	// Track the first allow and disallow rule of the longest length
+	// as allow wins ties and otherwise the first rule in the file wins
+	length, allow, disallow := -1, -1, -1
+	consider := func(i int) {
+		switch l := len(rules.rulePath(i)); {
+		case l > length:
+			length, allow, disallow = l, -1, -1
+			fallthrough
+		case l == length:
+			if allowed := rules.ruleAllowed(i); allowed && (allow < 0 || i < allow) {
+				allow = i
+			} else if !allowed && (disallow < 0 || i < disallow) {
+				disallow = i
+			}
+		}
+	}
+
+	if n := idx.longestPrefix(path); n != nil {
+		if n.firstAllow > -1 {
+			consider(n.firstAllow)
+		}
+
+		if n.firstDisallow > -1 {
+			consider(n.firstDisallow)
+		}
+	}
+
+	for _, i := range idx.patterns {
+		if rules.ruleMatches(i, path) {
+			consider(i)
+		}
+	}
+
+	switch {
+	case allow > -1 && disallow > -1:
+		return allow, ReasonAllowWinsTie
+	case allow > -1:
+		return allow, ReasonLongestMatch
+	case disallow > -1:
+		return disallow, ReasonLongestMatch
+	}
+
+	return -1, ReasonNoMatch
+}
+
+func commonPrefixLength(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+
+	return i
+}
@@ -0,0 +1,97 @@
+package robotstxt
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestRuleIndex_sameDecisionsAsScanning(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200; i++ {
+		g := &group{}
+		for plain := 0; plain < minIndexedRules || rnd.Intn(20) > 0; {
+			path := randomString(rnd, "/ab", "/", 6)
+			if rnd.Intn(5) == 0 {
+				path = randomString(rnd, "/ab*", "/", 6) + "*"
+			} else {
+				plain++
+			}
+
+			g.rules = append(g.rules, newRule(path, rnd.Intn(2) == 0))
+		}
+
+		if g.ruleIndex() == nil {
+			t.Fatalf("Expected the group to be indexed")
+		}
+
+		for j := 0; j < 200; j++ {
+			path := randomString(rnd, "/ab", "/", 8)
+
+			for _, precedence := range []Precedence{LongestMatch, LegacyOrder} {
+				expected, expectedReason := g.matchRules(path, precedence, nil)
+				actual, actualReason := g.ruleIndex().match(g, path, precedence)
+
+				if expected != actual || expectedReason != actualReason {
+					t.Fatalf("Expected %q to match rule %d (%s), got %d (%s)", path, expected, expectedReason, actual, actualReason)
+				}
+			}
+		}
+	}
+}
+
+func TestRuleIndex_smallGroupsAreNotIndexed(t *testing.T) {
+	robots, _ := Parse("User-agent: *\nDisallow: /a\nDisallow: /b\n", "http://www.example.com/robots.txt")
+
+	if robots.groups["*"].ruleIndex() != nil {
+		t.Errorf("Expected a group with 2 rules not to be indexed")
+	}
+}
+
+// largeRobotsTxt returns a robots.txt file with n disallowed
+// directories and an allowed file in every tenth one
+func largeRobotsTxt(n int) string {
+	var sb strings.Builder
+	sb.WriteString("User-agent: *\n")
+
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "Disallow: /section-%d/private/\n", i)
+		if i%10 == 0 {
+			fmt.Fprintf(&sb, "Allow: /section-%d/private/index.html\n", i)
+		}
+	}
+
+	sb.WriteString("Disallow: /*.pdf$\n")
+
+	return sb.String()
+}
+
+func BenchmarkGroupMatch(b *testing.B) {
+	paths := []string{
+		"/section-500/private/index.html",
+		"/section-123/private/page.html",
+		"/section-9999/public/page.html",
+		"/other/file.pdf",
+	}
+
+	for _, n := range []int{100, 10000, 50000} {
+		robots, _ := Parse(largeRobotsTxt(n), "http://www.example.com/robots.txt")
+		g := robots.groups["*"]
+
+		b.Run(fmt.Sprintf("Indexed/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				g.match(paths[i%len(paths)], LongestMatch, nil)
+			}
+		})
+
+		b.Run(fmt.Sprintf("Scanning/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				g.matchRules(paths[i%len(paths)], LongestMatch, nil)
+			}
+		})
+	}
+}
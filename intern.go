@@ -0,0 +1,77 @@
+package robotstxt
+
+import (
+	"strings"
+	"sync"
+)
+
+// maxInterned is the most strings and globs the intern pool will hold
+// so that hostile files can not grow it without limit. Values are
+// still copied once it is full, just not shared.
+const maxInterned = 1 << 16
+
+// internPool shares user agents and compiled wildcard rules between
+// Compact values as many sites use the same ones
+type internPool struct {
+	mu      sync.RWMutex
+	strings map[string]string
+	globs   map[string]*glob
+}
+
+var interned = &internPool{
+	strings: make(map[string]string),
+	globs:   make(map[string]*glob),
+}
+
+// string returns a shared copy of s
+func (p *internPool) string(s string) string {
+	p.mu.RLock()
+	shared, ok := p.strings[s]
+	p.mu.RUnlock()
+
+	if ok {
+		return shared
+	}
+
+	s = strings.Clone(s)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if shared, ok := p.strings[s]; ok {
+		return shared
+	}
+
+	if len(p.strings) < maxInterned {
+		p.strings[s] = s
+	}
+
+	return s
+}
+
+// glob returns a shared glob for the unescaped path of a wildcard rule
+func (p *internPool) glob(path string) *glob {
+	p.mu.RLock()
+	shared, ok := p.globs[path]
+	p.mu.RUnlock()
+
+	if ok {
+		return shared
+	}
+
+	path = strings.Clone(path)
+	g := compileGlob(path)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if shared, ok := p.globs[path]; ok {
+		return shared
+	}
+
+	if len(p.globs) < maxInterned {
+		p.globs[path] = g
+	}
+
+	return g
+}
@@ -0,0 +1,279 @@
+package robotstxt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// jsonRobotsTxt is the JSON schema of a RobotsTxt. See MarshalJSON.
+type jsonRobotsTxt struct {
+	URL               string              `json:"url"`
+	Precedence        string              `json:"precedence"`
+	UserAgentMatching string              `json:"userAgentMatching"`
+	Fallbacks         map[string][]string `json:"fallbacks,omitempty"`
+	Groups            []jsonGroup         `json:"groups"`
+	Sitemaps          []string            `json:"sitemaps"`
+	Host              string              `json:"host"`
+	Truncated         bool                `json:"truncated"`
+}
+
+type jsonGroup struct {
+	UserAgents []jsonUserAgent `json:"userAgents"`
+	Rules      []jsonRule      `json:"rules"`
+	CrawlDelay *float64        `json:"crawlDelay,omitempty"`
+}
+
+type jsonUserAgent struct {
+	Name string `json:"name"`
+	Line int    `json:"line"`
+}
+
+type jsonRule struct {
+	Allowed  bool   `json:"allowed"`
+	Value    string `json:"value"`
+	Path     string `json:"path"`
+	Wildcard bool   `json:"wildcard"`
+	Line     int    `json:"line"`
+}
+
+var precedenceNames = map[Precedence]string{
+	LongestMatch: "longest-match",
+	LegacyOrder:  "legacy-order",
+}
+
+var userAgentMatchingNames = map[UserAgentMatching]string{
+	MatchExact:        "exact",
+	MatchProductToken: "product-token",
+}
+
+// errUnknownJSONValue is the error UnmarshalJSON returns for an unknown
+// precedence or user agent matching name
+func errUnknownJSONValue(field, name string) error {
+	return fmt.Errorf("robotstxt: unknown JSON %s %q", field, name)
+}
+
+// MarshalJSON implements json.Marshaler. The schema is stable and
+// looks like:
+//
+//	{
+//	  "url": "http://www.example.com/robots.txt",
+//	  "precedence": "longest-match",
+//	  "userAgentMatching": "exact",
+//	  "fallbacks": {"googlebot-news": ["googlebot"]},
+//	  "groups": [
+//	    {
+//	      "userAgents": [{"name": "googlebot", "line": 1}],
+//	      "rules": [
+//	        {"allowed": false, "value": "/*.PDF$", "path": "/*.PDF$", "wildcard": true, "line": 2}
+//	      ],
+//	      "crawlDelay": 2.5
+//	    }
+//	  ],
+//	  "sitemaps": ["http://www.example.com/sitemap.xml"],
+//	  "host": "www.example.com",
+//	  "truncated": false
+//	}
+//
+// Precedence is "longest-match" or "legacy-order" and userAgentMatching
+// is "exact" or "product-token". Groups are in file order with
+// normalised user agent names. Rule values are as they appear in the
+// file and paths are after normalisation and URL decoding. Crawl delays
+// are in seconds and omitted if the group has none. Fallbacks is
+// omitted if there are none.
+//
+// Directives, diagnostics and fetch information are not included.
+func (r *RobotsTxt) MarshalJSON() ([]byte, error) {
+	j := jsonRobotsTxt{
+		URL:               r.url.String(),
+		Precedence:        precedenceNames[r.precedence],
+		UserAgentMatching: userAgentMatchingNames[r.matching],
+		Fallbacks:         r.fallbacks,
+		Groups:            []jsonGroup{},
+		Sitemaps:          r.sitemaps,
+		Host:              r.host,
+		Truncated:         r.truncated,
+	}
+
+	if j.Sitemaps == nil {
+		j.Sitemaps = []string{}
+	}
+
+	for _, record := range r.records {
+		// User agents with nothing after them before the end of the
+		// file or another directive do not form a group
+		if !r.recordHasGroups(record) {
+			continue
+		}
+
+		group := jsonGroup{Rules: []jsonRule{}}
+
+		for _, ua := range record.userAgents {
+			group.UserAgents = append(group.UserAgents, jsonUserAgent{Name: ua.name, Line: ua.line})
+		}
+
+		for _, rule := range record.rules {
+			group.Rules = append(group.Rules, jsonRule{
+				Allowed:  rule.isAllowed,
+				Value:    rule.value,
+				Path:     rule.path,
+				Wildcard: rule.pattern != nil,
+				Line:     rule.line,
+			})
+		}
+
+		if record.hasCrawlDelay {
+			seconds := record.crawlDelay.Seconds()
+			group.CrawlDelay = &seconds
+		}
+
+		j.Groups = append(j.Groups, group)
+	}
+
+	return json.Marshal(j)
+}
+
+// recordHasGroups returns true if the record created or added to the
+// groups of its user agents
+func (r *RobotsTxt) recordHasGroups(record *record) bool {
+	for _, ua := range record.userAgents {
+		if g, ok := r.groups[ua.name]; !ok || g.line > ua.line {
+			return false
+		}
+	}
+
+	return true
+}
+
+// UnmarshalJSON implements json.Unmarshaler for the schema described
+// by MarshalJSON. Rules are compiled from their values, the path and
+// wildcard fields are ignored. A group with no rules still applies to
+// its user agents, the same as a group with an empty Disallow.
+func (r *RobotsTxt) UnmarshalJSON(data []byte) error {
+	var j jsonRobotsTxt
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	u, err := parseAndNormalizeURL(j.URL)
+	if err != nil {
+		return err
+	}
+
+	loaded := RobotsTxt{
+		url:       u,
+		groups:    make(map[string]*group),
+		host:      j.Host,
+		truncated: j.Truncated,
+	}
+
+	if len(j.Sitemaps) > 0 {
+		loaded.sitemaps = j.Sitemaps
+	}
+
+	if loaded.precedence, err = parsePrecedenceName(j.Precedence); err != nil {
+		return err
+	}
+
+	if loaded.matching, err = parseUserAgentMatchingName(j.UserAgentMatching); err != nil {
+		return err
+	}
+
+	var o options
+	for userAgent, fallbacks := range j.Fallbacks {
+		WithFallbacks(userAgent, fallbacks...)(&o)
+	}
+	loaded.fallbacks = o.fallbacks
+
+	for _, jg := range j.Groups {
+		if len(jg.UserAgents) == 0 {
+			return errors.New("robotstxt: JSON group has no user agents")
+		}
+
+		record := &record{}
+		for _, ua := range jg.UserAgents {
+			record.userAgents = append(record.userAgents, userAgentLine{
+				name: normaliseUserAgent(ua.Name),
+				line: ua.Line,
+			})
+		}
+
+		for _, ua := range record.userAgents {
+			loaded.getGroup(ua)
+		}
+
+		for _, jr := range jg.Rules {
+			path, _, _ := normaliseRuleValue(jr.Value)
+			rule := newRule(path, jr.Allowed)
+			if rule == nil {
+				continue
+			}
+
+			directive := "Disallow: "
+			if jr.Allowed {
+				directive = "Allow: "
+			}
+
+			rule.value = jr.Value
+			rule.line = jr.Line
+			rule.text = directive + jr.Value
+			record.rules = append(record.rules, rule)
+
+			for _, ua := range record.userAgents {
+				loaded.addRule(ua, rule)
+			}
+		}
+
+		if jg.CrawlDelay != nil {
+			if delay := *jg.CrawlDelay; delay < 0 || delay*float64(time.Second) >= math.MaxInt64 {
+				return errors.New("robotstxt: invalid JSON crawl delay")
+			}
+
+			record.crawlDelay = time.Duration(*jg.CrawlDelay * float64(time.Second))
+			record.hasCrawlDelay = true
+			for _, ua := range record.userAgents {
+				loaded.getGroup(ua).crawlDelay = record.crawlDelay
+			}
+		}
+
+		loaded.records = append(loaded.records, record)
+	}
+
+	*r = loaded
+
+	return nil
+}
+
+// parsePrecedenceName returns the Precedence for a name used in JSON,
+// defaulting to LongestMatch if it is empty
+func parsePrecedenceName(name string) (Precedence, error) {
+	for precedence, n := range precedenceNames {
+		if n == name {
+			return precedence, nil
+		}
+	}
+
+	if name != "" {
+		return LongestMatch, errUnknownJSONValue("precedence", name)
+	}
+
+	return LongestMatch, nil
+}
+
+// parseUserAgentMatchingName returns the UserAgentMatching for a name
+// used in JSON, defaulting to MatchExact if it is empty
+func parseUserAgentMatchingName(name string) (UserAgentMatching, error) {
+	for matching, n := range userAgentMatchingNames {
+		if n == name {
+			return matching, nil
+		}
+	}
+
+	if name != "" {
+		return MatchExact, errUnknownJSONValue("userAgentMatching", name)
+	}
+
+	return MatchExact, nil
+}
@@ -0,0 +1,132 @@
+package robotstxt
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRobotsTxt_jsonRoundTrip(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := append([]string{
+		largeRobotsTxt(50),
+		"User-agent: a\nSitemap: /s.xml\nUser-agent: a\nUser-agent: b\nDisallow:\n",
+		"User-agent: a\nDisallow: /a\nUser-agent: b\n",
+		"User-agent: a\nCrawl-delay: x\nUser-agent: *\nDisallow: /\n",
+	}, roundTripContents...)
+
+	optionSets := [][]Option{
+		nil,
+		{WithPrecedence(LegacyOrder)},
+		{WithUserAgentMatching(MatchProductToken), WithFallbacks("agentb-news", "agentb")},
+	}
+
+	for _, c := range contents {
+		for _, opts := range optionSets {
+			original, _ := Parse(c, url, opts...)
+
+			data, err := json.Marshal(original)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var loaded RobotsTxt
+			if err := json.Unmarshal(data, &loaded); err != nil {
+				t.Fatalf("Unexpected error %v for:\n%s", err, data)
+			}
+
+			assertSameDecisions(t, original, &loaded)
+
+			for _, userAgent := range []string{"*", "a", "b", "agentb-news", "Mozilla/5.0 (compatible; AgentB/1.0)"} {
+				for _, path := range []string{"/", "/a", "/fish/", "/section-10/private/index.html", "/a.pdf"} {
+					expected, _ := original.Explain(userAgent, "http://www.example.com"+path)
+					actual, _ := loaded.Explain(userAgent, "http://www.example.com"+path)
+
+					if expected.Allowed != actual.Allowed || expected.UserAgentLine != actual.UserAgentLine || expected.Reason != actual.Reason {
+						t.Errorf("Expected %+v for %s %s, got %+v in:\n%s", expected, userAgent, path, actual, data)
+					}
+				}
+			}
+
+			again, _ := json.Marshal(&loaded)
+			if string(again) != string(data) {
+				t.Errorf("Expected encoding to be stable, got:\n%s\n%s", data, again)
+			}
+		}
+	}
+}
+
+func TestRobotsTxt_jsonSchema(t *testing.T) {
+	robots, _ := Parse(
+		"User-agent: Googlebot\nDisallow: /*.PDF$\nCrawl-delay: 2.5\n\nUser-agent: b\nSitemap: http://www.example.com/sitemap.xml\nHost: www.example.com\n",
+		"http://www.example.com/robots.txt",
+		WithFallbacks("Googlebot-News", "Googlebot"),
+	)
+
+	data, _ := json.Marshal(robots)
+
+	expected := `{"url":"http://www.example.com/robots.txt","precedence":"longest-match","userAgentMatching":"exact",` +
+		`"fallbacks":{"googlebot-news":["googlebot"]},` +
+		`"groups":[{"userAgents":[{"name":"googlebot","line":1}],` +
+		`"rules":[{"allowed":false,"value":"/*.PDF$","path":"/*.PDF$","wildcard":true,"line":2}],"crawlDelay":2.5}],` +
+		`"sitemaps":["http://www.example.com/sitemap.xml"],"host":"www.example.com","truncated":false}`
+
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestRobotsTxt_jsonFromOtherProducers(t *testing.T) {
+	var robots RobotsTxt
+	err := json.Unmarshal([]byte(`{
+		"url": "http://www.example.com/robots.txt",
+		"groups": [
+			{"userAgents": [{"name": "Bot/1.0"}], "rules": [{"value": "private"}, {"allowed": true, "value": "/private/ok"}]},
+			{"userAgents": [{"name": "other"}]}
+		]
+	}`), &robots)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		userAgent, path string
+		allowed         bool
+	}{
+		{"bot", "/private/no", false},
+		{"bot", "/private/ok", true},
+		{"other", "/private/no", true},
+	}
+
+	for _, test := range tests {
+		if allowed, _ := robots.IsAllowed(test.userAgent, "http://www.example.com"+test.path); allowed != test.allowed {
+			t.Errorf("Expected %s for %s to be %v", test.path, test.userAgent, test.allowed)
+		}
+	}
+
+	if !reflect.DeepEqual(robots.UserAgents(), []string{"bot", "other"}) {
+		t.Errorf("Expected user agents bot and other, got %v", robots.UserAgents())
+	}
+}
+
+func TestRobotsTxt_jsonInvalid(t *testing.T) {
+	tests := []string{
+		`{"url": "http://www.example.com/robots.txt", "precedence": "shortest"}`,
+		`{"url": "http://www.example.com/robots.txt", "userAgentMatching": "fuzzy"}`,
+		`{"url": "http://www.example.com/robots.txt", "groups": [{"rules": []}]}`,
+		`{"url": "http://www.example.com/robots.txt", "groups": [{"userAgents": [{"name": "a"}], "crawlDelay": -5}]}`,
+		`{"url": "http://www.example.com/robots.txt", "groups": [{"userAgents": [{"name": "a"}], "crawlDelay": 1e300}]}`,
+		`{"url": "%"}`,
+		`[]`,
+	}
+
+	for _, test := range tests {
+		var robots RobotsTxt
+		if err := json.Unmarshal([]byte(test), &robots); err == nil {
+			t.Errorf("Expected an error for %s", test)
+		} else if strings.Contains(test, "shortest") && !strings.Contains(err.Error(), "shortest") {
+			t.Errorf("Expected the error to name the unknown value, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package robotstxt
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultIdleTimeout is how long a Limiter keeps state for a host
+// after its last reserved request
+const DefaultIdleTimeout = 10 * time.Minute
+
+// Limiter spaces requests to each host by the crawl delay in the host's
+// robots.txt file. It is safe for concurrent use.
+//
+// The zero value is ready to use.
+type Limiter struct {
+	// Cache is used to fetch robots.txt files. If nil, a Cache
+	// using a client with a timeout of DefaultFetchTimeout is
+	// created on first use.
+	Cache *Cache
+
+	// UserAgent is the user agent to use the crawl delay for
+	UserAgent string
+
+	// DefaultDelay is the delay used for hosts without a crawl delay
+	DefaultDelay time.Duration
+
+	// MaxDelay caps crawl delays from robots.txt files. If 0 or
+	// less there is no maximum.
+	MaxDelay time.Duration
+
+	// IdleTimeout is how long to keep state for hosts that have no
+	// pending requests. Defaults to DefaultIdleTimeout.
+	IdleTimeout time.Duration
+
+	once  sync.Once
+	cache *Cache
+
+	mu        sync.Mutex
+	hosts     map[string]*limiterHost
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type limiterHost struct {
+	// next is the earliest time the next request can be made
+	next time.Time
+}
+
+// Reserve reserves the next slot for a request to the host of urlStr
+// and returns how long the caller must wait before making the request.
+// Reserving a slot can not be undone so callers must make the request.
+//
+// The robots.txt file is fetched if needed using context.Background,
+// use Wait to be able to cancel.
+func (l *Limiter) Reserve(urlStr string) (time.Duration, error) {
+	delay, _, err := l.reserve(context.Background(), urlStr)
+	return delay, err
+}
+
+// Wait blocks until a request can be made to the host of urlStr or
+// ctx is done. If ctx is done first the slot is released if possible
+// and ctx's error returned.
+func (l *Limiter) Wait(ctx context.Context, urlStr string) error {
+	delay, cancel, err := l.reserve(ctx, urlStr)
+	if err != nil {
+		return err
+	}
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve returns the delay before the request can be made and a
+// function to release the reservation
+func (l *Limiter) reserve(ctx context.Context, urlStr string) (time.Duration, func(), error) {
+	u, err := parseAndNormalizeURL(urlStr)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	robots, err := l.getCache().Get(ctx, urlStr)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	crawlDelay := robots.CrawlDelay(l.UserAgent)
+	if crawlDelay <= 0 {
+		crawlDelay = l.DefaultDelay
+	}
+	if l.MaxDelay > 0 && crawlDelay > l.MaxDelay {
+		crawlDelay = l.MaxDelay
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.timeNow()
+	l.sweep(now)
+
+	if l.hosts == nil {
+		l.hosts = make(map[string]*limiterHost)
+	}
+
+	host, ok := l.hosts[u.Host]
+	if !ok {
+		host = &limiterHost{}
+		l.hosts[u.Host] = host
+	}
+
+	start := host.next
+	if start.Before(now) {
+		start = now
+	}
+
+	end := start.Add(crawlDelay)
+	host.next = end
+
+	cancel := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		// Can only release the slot if no later
+		// requests have been reserved
+		if host.next.Equal(end) {
+			host.next = start
+		}
+	}
+
+	return start.Sub(now), cancel, nil
+}
+
+// sweep removes hosts that have been idle for longer than IdleTimeout.
+// It runs at most once per IdleTimeout to keep reservations cheap.
+func (l *Limiter) sweep(now time.Time) {
+	idleTimeout := l.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultIdleTimeout
+	}
+
+	if now.Sub(l.lastSweep) < idleTimeout {
+		return
+	}
+
+	l.lastSweep = now
+
+	for key, host := range l.hosts {
+		if now.Sub(host.next) > idleTimeout {
+			delete(l.hosts, key)
+		}
+	}
+}
+
+func (l *Limiter) getCache() *Cache {
+	l.once.Do(func() {
+		l.cache = l.Cache
+		if l.cache == nil {
+			l.cache = &Cache{Client: &http.Client{Timeout: DefaultFetchTimeout}}
+		}
+	})
+
+	return l.cache
+}
+
+func (l *Limiter) timeNow() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+
+	return time.Now()
+}
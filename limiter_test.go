@@ -0,0 +1,171 @@
+package robotstxt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newCrawlDelayServer(crawlDelay string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nCrawl-delay: "+crawlDelay)
+	}))
+}
+
+func TestLimiter_spaceRequestsByCrawlDelay(t *testing.T) {
+	server := newCrawlDelayServer("2")
+	defer server.Close()
+
+	clock := &testClock{now: time.Now()}
+	limiter := &Limiter{
+		Cache:     &Cache{Client: server.Client()},
+		UserAgent: "bot",
+		now:       clock.Now,
+	}
+
+	expected := []time.Duration{0, 2 * time.Second, 4 * time.Second}
+	for i, delay := range expected {
+		actual, err := limiter.Reserve(server.URL + "/page")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual != delay {
+			t.Errorf("Expected reservation %d to wait %v, got %v", i, delay, actual)
+		}
+	}
+
+	clock.Add(10 * time.Second)
+
+	actual, _ := limiter.Reserve(server.URL + "/page")
+	if actual != 0 {
+		t.Errorf("Expected no wait after the delay has passed, got %v", actual)
+	}
+}
+
+func TestLimiter_applyDefaultAndMaxDelay(t *testing.T) {
+	noDelay := newCrawlDelayServer("")
+	defer noDelay.Close()
+
+	longDelay := newCrawlDelayServer("3600")
+	defer longDelay.Close()
+
+	clock := &testClock{now: time.Now()}
+	limiter := &Limiter{
+		Cache:        &Cache{Client: noDelay.Client()},
+		DefaultDelay: time.Second,
+		MaxDelay:     time.Minute,
+		now:          clock.Now,
+	}
+
+	limiter.Reserve(noDelay.URL)
+	if actual, _ := limiter.Reserve(noDelay.URL); actual != time.Second {
+		t.Errorf("Expected the default delay, got %v", actual)
+	}
+
+	limiter.Reserve(longDelay.URL)
+	if actual, _ := limiter.Reserve(longDelay.URL); actual != time.Minute {
+		t.Errorf("Expected the max delay, got %v", actual)
+	}
+}
+
+func TestLimiter_waitForConcurrentRequests(t *testing.T) {
+	server := newCrawlDelayServer("0.02")
+	defer server.Close()
+
+	limiter := &Limiter{Cache: &Cache{Client: server.Client()}}
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := limiter.Wait(context.Background(), server.URL); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected 5 requests to take at least 80ms, took %v", elapsed)
+	}
+}
+
+func TestLimiter_releaseSlotWhenContextIsDone(t *testing.T) {
+	server := newCrawlDelayServer("60")
+	defer server.Close()
+
+	limiter := &Limiter{Cache: &Cache{Client: server.Client()}}
+	limiter.Reserve(server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx, server.URL); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	delay, _ := limiter.Reserve(server.URL)
+	if delay > 60*time.Second {
+		t.Errorf("Expected the cancelled slot to be released, got %v", delay)
+	}
+}
+
+func TestLimiter_expireIdleHosts(t *testing.T) {
+	server := newCrawlDelayServer("1")
+	defer server.Close()
+
+	clock := &testClock{now: time.Now()}
+	limiter := &Limiter{
+		IdleTimeout: time.Minute,
+		now:         clock.Now,
+	}
+
+	limiter.Reserve(server.URL)
+	if len(limiter.hosts) != 1 {
+		t.Fatalf("Expected 1 host, got %d", len(limiter.hosts))
+	}
+
+	clock.Add(2 * time.Minute)
+
+	other := newCrawlDelayServer("1")
+	defer other.Close()
+	limiter.Reserve(other.URL)
+
+	if _, ok := limiter.hosts[server.Listener.Addr().String()]; ok {
+		t.Errorf("Expected the idle host to be removed")
+	}
+}
+
+func TestLimiter_timeOutWhenRobotsTxtHangs(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	limiter := &Limiter{
+		Cache:     &Cache{Client: server.Client(), FetchTimeout: 50 * time.Millisecond},
+		UserAgent: "bot",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := limiter.Wait(ctx, server.URL+"/page"); err != nil {
+		t.Errorf("Expected Wait to return once the fetch times out, got %v", err)
+	}
+
+	if timeout := (&Limiter{}).getCache().Client.Timeout; timeout != DefaultFetchTimeout {
+		t.Errorf("Expected the default cache to time out after %v, got %v", DefaultFetchTimeout, timeout)
+	}
+}
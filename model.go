@@ -0,0 +1,121 @@
+package robotstxt
+
+import "time"
+
+// Directive is a "name: value" line from a robots.txt file
+type Directive struct {
+	// Name is the lower case directive name
+	Name string
+	// Value is the value with surrounding whitespace and
+	// comments removed
+	Value string
+	// Line is the 1-based line number of the directive
+	Line int
+}
+
+// Rule is an allow or disallow rule
+type Rule struct {
+	// Allowed is true for allow rules and false for disallow rules
+	Allowed bool
+	// Value is the value as it appears in the file
+	Value string
+	// Path is the path or pattern the rule matches after normalisation
+	// and URL decoding
+	Path string
+	// Wildcard is true if the rule contains * or ends with $
+	Wildcard bool
+	// Line is the 1-based line number of the rule
+	Line int
+}
+
+// Group is a group as it appears in a robots.txt file: one or more
+// user-agent lines followed by the rules that apply to them
+type Group struct {
+	// UserAgents are the normalised user agent names of the group
+	UserAgents []string
+	// Rules are the allow and disallow rules of the group in file
+	// order. Rules with no value match nothing and are not included.
+	Rules []Rule
+	// CrawlDelay is the crawl delay of the group or 0 if there is none
+	CrawlDelay time.Duration
+	// Line is the 1-based line number of the first user-agent line
+	Line int
+}
+
+func newRuleModel(rule *rule) Rule {
+	return Rule{
+		Allowed:  rule.isAllowed,
+		Value:    rule.value,
+		Path:     rule.path,
+		Wildcard: rule.pattern != nil,
+		Line:     rule.line,
+	}
+}
+
+func newRuleModels(rules []*rule) []Rule {
+	models := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		models = append(models, newRuleModel(rule))
+	}
+
+	return models
+}
+
+// Groups returns the groups in the robots.txt file in file order.
+// A user agent listed in more than one group uses the rules from
+// all of them.
+func (r *RobotsTxt) Groups() []Group {
+	groups := make([]Group, 0, len(r.records))
+	for _, record := range r.records {
+		group := Group{
+			Rules:      newRuleModels(record.rules),
+			CrawlDelay: record.crawlDelay,
+			Line:       record.userAgents[0].line,
+		}
+
+		for _, ua := range record.userAgents {
+			group.UserAgents = append(group.UserAgents, ua.name)
+		}
+
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// UserAgents returns the normalised user agents the robots.txt file
+// has groups for in the order they first appear
+func (r *RobotsTxt) UserAgents() []string {
+	var userAgents []string
+	seen := make(map[string]bool)
+
+	for _, record := range r.records {
+		for _, ua := range record.userAgents {
+			if !seen[ua.name] {
+				seen[ua.name] = true
+				userAgents = append(userAgents, ua.name)
+			}
+		}
+	}
+
+	return userAgents
+}
+
+// Rules returns the rules that apply to the user agent in file order,
+// taken from the same group IsAllowed would use
+func (r *RobotsTxt) Rules(userAgent string) []Rule {
+	if _, group := r.findGroup(userAgent); group != nil {
+		return newRuleModels(group.rules)
+	}
+
+	return nil
+}
+
+// Directives returns every directive in the robots.txt file in file
+// order, including ones this package does not support
+func (r *RobotsTxt) Directives() []Directive {
+	directives := make([]Directive, len(r.directives))
+	copy(directives, r.directives)
+
+	return directives
+}
@@ -0,0 +1,89 @@
+package robotstxt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRobotsTxt_groupsInFileOrder(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := "User-agent: b\n" +
+		"User-agent: A/1.0\n" +
+		"Disallow: /fish*.php\n" +
+		"Allow: private\n" +
+		"Disallow:\n" +
+		"Crawl-delay: 2\n" +
+		"\n" +
+		"User-agent: *\n" +
+		"Disallow: /%E6%B5%8B\n" +
+		"\n" +
+		"User-agent: b\n" +
+		"Allow: /b\n"
+
+	robots, _ := Parse(contents, url)
+
+	expected := []Group{
+		{
+			UserAgents: []string{"b", "a"},
+			Rules: []Rule{
+				{Allowed: false, Value: "/fish*.php", Path: "/fish*.php", Wildcard: true, Line: 3},
+				{Allowed: true, Value: "private", Path: "/private", Line: 4},
+			},
+			CrawlDelay: 2 * time.Second,
+			Line:       1,
+		},
+		{
+			UserAgents: []string{"*"},
+			Rules: []Rule{
+				{Allowed: false, Value: "/%E6%B5%8B", Path: "/测", Line: 9},
+			},
+			Line: 8,
+		},
+		{
+			UserAgents: []string{"b"},
+			Rules: []Rule{
+				{Allowed: true, Value: "/b", Path: "/b", Line: 12},
+			},
+			Line: 11,
+		},
+	}
+
+	if actual := robots.Groups(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected groups %+v, got %+v", expected, actual)
+	}
+
+	if actual := robots.UserAgents(); !reflect.DeepEqual(actual, []string{"b", "a", "*"}) {
+		t.Errorf("Expected user agents b, a, *, got %v", actual)
+	}
+
+	rules := robots.Rules("B")
+	if len(rules) != 3 || rules[0].Line != 3 || rules[2].Line != 12 {
+		t.Errorf("Expected the rules from both b groups, got %+v", rules)
+	}
+
+	rules = robots.Rules("other")
+	if len(rules) != 1 || rules[0].Line != 9 {
+		t.Errorf("Expected the rules from the * group, got %+v", rules)
+	}
+}
+
+func TestRobotsTxt_directivesInFileOrder(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := "Sitemap: http://www.example.com/sitemap.xml\n" +
+		"invalid line\n" +
+		"User-Agent: * # all\n" +
+		"Noindex: /page\n"
+
+	robots, _ := Parse(contents, url)
+
+	expected := []Directive{
+		{Name: "sitemap", Value: "http://www.example.com/sitemap.xml", Line: 1},
+		{Name: "user-agent", Value: "*", Line: 3},
+		{Name: "noindex", Value: "/page", Line: 4},
+	}
+
+	if actual := robots.Directives(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected directives %+v, got %+v", expected, actual)
+	}
+}
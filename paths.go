@@ -0,0 +1,498 @@
+package robotstxt
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxExploredStates and maxExploredSteps limit how much work
+// explorePaths does for pathological sets of wildcard rules. Rules
+// without wildcards do not count towards them. A step is moving one
+// pattern on by one character.
+const (
+	maxExploredStates = 1 << 16
+	maxExploredSteps  = 1 << 23
+)
+
+// pathPattern is the formal model of how a rule matches paths: a path
+// matches if it starts with the segments in order, with any characters
+// in place of the * between them, and if the pattern is anchored there
+// is nothing after the last segment. Plain rules are a single segment
+// that is not anchored.
+type pathPattern struct {
+	segments []string
+	anchored bool
+}
+
+// newPathPattern returns the model of the rule
+func newPathPattern(r *rule) pathPattern {
+	if r.pattern == nil {
+		return pathPattern{segments: []string{r.path}}
+	}
+
+	return pathPattern{segments: r.pattern.segments, anchored: r.pattern.anchored}
+}
+
+// matches checks if the path matches the pattern
+func (p pathPattern) matches(path string) bool {
+	tokens := p.tokens()
+	states := closure(tokens, patternStates{0})
+
+	for i := 0; i < len(path) && len(states) > 0; i++ {
+		states = step(tokens, states, path[i])
+	}
+
+	return len(states) > 0 && states[len(states)-1] == len(tokens)
+}
+
+// key returns a string that is equal for patterns that are equal
+func (p pathPattern) key() string {
+	var sb strings.Builder
+	for _, segment := range p.segments {
+		sb.WriteString(strconv.Quote(segment))
+	}
+
+	if p.anchored {
+		sb.WriteString("$")
+	}
+
+	return sb.String()
+}
+
+// starToken is the token for a * in a pathPattern's tokens
+const starToken = -1
+
+// tokens returns the pattern as bytes and stars. Patterns that are not
+// anchored end with a star.
+func (p pathPattern) tokens() []int {
+	var tokens []int
+	for i, segment := range p.segments {
+		if i > 0 {
+			tokens = append(tokens, starToken)
+		}
+
+		for j := 0; j < len(segment); j++ {
+			tokens = append(tokens, int(segment[j]))
+		}
+	}
+
+	if !p.anchored {
+		tokens = append(tokens, starToken)
+	}
+
+	return tokens
+}
+
+// patternStates is the set of positions in the tokens of a pattern
+// that a path can have matched up to
+type patternStates []int
+
+// closure adds the positions after stars as a star can match nothing
+func closure(tokens []int, states patternStates) patternStates {
+	for i := 0; i < len(states); i++ {
+		if pos := states[i]; pos < len(tokens) && tokens[pos] == starToken {
+			states = append(states, pos+1)
+		}
+	}
+
+	sort.Ints(states)
+
+	result := states[:0]
+	for i, pos := range states {
+		if i == 0 || pos != states[i-1] {
+			result = append(result, pos)
+		}
+	}
+
+	return result
+}
+
+// step returns the positions after reading c
+func step(tokens []int, states patternStates, c byte) patternStates {
+	var next patternStates
+	for _, pos := range states {
+		switch {
+		case pos == len(tokens):
+		case tokens[pos] == starToken:
+			next = append(next, pos)
+		case tokens[pos] == int(c):
+			next = append(next, pos+1)
+		}
+	}
+
+	next = closure(tokens, next)
+
+	// Once a pattern ending in a star has matched it always matches
+	// so the other positions can be dropped
+	end := len(tokens)
+	if n := len(next); n > 1 && next[n-1] == end && tokens[end-1] == starToken {
+		next = patternStates{end - 1, end}
+	}
+
+	return next
+}
+
+// explorePaths calls visit with the shortest path for each distinct set
+// of rules that match some path together. As whether a path is allowed
+// only depends on which rules match it, this covers every decision the
+// rules can make. It stops when visit returns false and returns false if
+// the rules were too complex to explore fully.
+//
+// Rules without wildcards are walked as a trie of their paths so only
+// rules with wildcards count towards maxExploredStates and
+// maxExploredSteps.
+func explorePaths(rules []*rule, visit func(path string) bool) bool {
+	e := newPathExplorer(rules)
+	complete := e.explore()
+
+	paths := make([]string, 0, len(e.shortest))
+	for _, path := range e.shortest {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if len(paths[i]) != len(paths[j]) {
+			return len(paths[i]) < len(paths[j])
+		}
+
+		return paths[i] < paths[j]
+	})
+
+	for _, path := range paths {
+		if !visit(path) {
+			return true
+		}
+	}
+
+	return complete
+}
+
+// pathExplorer finds the paths for explorePaths. A plain rule matches
+// every path that starts with its path, so while a path follows the
+// trie of plain paths it is walked one node at a time. Once it leaves
+// the trie only the wildcard patterns can match differently, so what
+// they can match from there is explored breadth first.
+type pathExplorer struct {
+	// plain is the sorted paths of the rules without wildcards
+	plain []string
+	// patterns is the tokens of the rules with wildcards
+	patterns [][]int
+	// alphabet is the bytes the patterns use and one byte no rule uses
+	// that stands in for all the others
+	alphabet []byte
+
+	states    int
+	steps     int
+	matchSets map[string]int
+	reachable map[string][]reachedMatches
+	left      map[leftKey]bool
+	// shortest is the shortest path found for each plain rule and set
+	// of matching patterns. The plain rule is the index in plain of the
+	// longest plain path the path starts with, or -1 if there is none.
+	shortest map[[2]int]string
+}
+
+// reachedMatches is a set of matching patterns and the shortest suffix
+// that reaches it
+type reachedMatches struct {
+	suffix  string
+	matches int
+}
+
+// leftKey is a plain rule and the pattern states a path left the trie
+// of plain paths with
+type leftKey struct {
+	terminal int
+	live     string
+}
+
+func newPathExplorer(rules []*rule) *pathExplorer {
+	e := &pathExplorer{
+		matchSets: make(map[string]int),
+		reachable: make(map[string][]reachedMatches),
+		left:      make(map[leftKey]bool),
+		shortest:  make(map[[2]int]string),
+	}
+
+	seen := make(map[string]bool)
+	used := make(map[byte]bool)
+	patternUsed := make(map[byte]bool)
+
+	for _, rule := range rules {
+		pattern := newPathPattern(rule)
+		key := pattern.key()
+		isNew := !seen[key]
+		seen[key] = true
+
+		for _, segment := range pattern.segments {
+			for i := 0; i < len(segment); i++ {
+				used[segment[i]] = true
+				if rule.pattern != nil {
+					patternUsed[segment[i]] = true
+				}
+			}
+		}
+
+		switch {
+		case !isNew:
+		case rule.pattern == nil:
+			e.plain = append(e.plain, rule.path)
+		default:
+			e.patterns = append(e.patterns, pattern.tokens())
+		}
+	}
+
+	sort.Strings(e.plain)
+
+	e.alphabet = []byte{fillerByte(used)}
+	for c := range patternUsed {
+		e.alphabet = append(e.alphabet, c)
+	}
+	sort.Slice(e.alphabet, func(i, j int) bool { return e.alphabet[i] < e.alphabet[j] })
+
+	return e
+}
+
+// explore finds the shortest paths, returning false if the patterns
+// were too complex to explore fully
+func (e *pathExplorer) explore() bool {
+	var start []livePattern
+	for i, tokens := range e.patterns {
+		start = append(start, livePattern{pattern: i, states: closure(tokens, patternStates{0})})
+	}
+
+	// Every path starts with a slash
+	live, ok := e.step(start, '/')
+	if !ok {
+		return false
+	}
+
+	lo := sort.SearchStrings(e.plain, "/")
+	hi := sort.SearchStrings(e.plain, "0")
+	if lo == hi {
+		return e.leave(-1, "/", live)
+	}
+
+	return e.walk(plainNode{depth: 1, lo: lo, hi: hi, terminal: -1, live: live})
+}
+
+// plainNode is a node of the trie of plain paths, the paths in
+// plain[lo:hi] that share their first depth bytes
+type plainNode struct {
+	depth    int
+	lo, hi   int
+	terminal int
+	live     []livePattern
+}
+
+// walk visits the trie of plain paths breadth first from start
+func (e *pathExplorer) walk(start plainNode) bool {
+	queue := []plainNode{start}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		prefix := e.plain[n.lo][:n.depth]
+		if len(e.plain[n.lo]) == n.depth {
+			n.terminal = n.lo
+			n.lo++
+		}
+
+		e.add(n.terminal, e.matches(n.live), prefix, "")
+
+		var children []byte
+		for i := n.lo; i < n.hi; {
+			c := e.plain[i][n.depth]
+			j := i + 1
+			for j < n.hi && e.plain[j][n.depth] == c {
+				j++
+			}
+
+			live, ok := e.step(n.live, c)
+			if !ok {
+				return false
+			}
+
+			children = append(children, c)
+			queue = append(queue, plainNode{depth: n.depth + 1, lo: i, hi: j, terminal: n.terminal, live: live})
+			i = j
+		}
+
+		for _, c := range e.alphabet {
+			if bytes.IndexByte(children, c) > -1 {
+				continue
+			}
+
+			live, ok := e.step(n.live, c)
+			if !ok || !e.leave(n.terminal, prefix+string([]byte{c}), live) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// leave adds the paths that start with prefix, which has just left the
+// trie of plain paths
+func (e *pathExplorer) leave(terminal int, prefix string, live []livePattern) bool {
+	// Leaving with the same plain rule and pattern states again can only
+	// find longer paths
+	key := leftKey{terminal: terminal, live: stateKey(live)}
+	if e.left[key] {
+		return true
+	}
+	e.left[key] = true
+
+	reached, ok := e.reach(live)
+	if !ok {
+		return false
+	}
+
+	for _, r := range reached {
+		e.add(terminal, r.matches, prefix, r.suffix)
+	}
+
+	return true
+}
+
+// reach returns the sets of patterns that can match after the pattern
+// states with the shortest suffix for each. It returns false if that
+// goes over maxExploredStates or maxExploredSteps.
+func (e *pathExplorer) reach(live []livePattern) ([]reachedMatches, bool) {
+	key := stateKey(live)
+	if reached, ok := e.reachable[key]; ok {
+		return reached, true
+	}
+
+	type state struct {
+		suffix string
+		live   []livePattern
+	}
+
+	var reached []reachedMatches
+	seen := map[string]bool{key: true}
+	seenMatches := make(map[int]bool)
+	queue := []state{{live: live}}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if matches := e.matches(current.live); !seenMatches[matches] {
+			seenMatches[matches] = true
+			reached = append(reached, reachedMatches{suffix: current.suffix, matches: matches})
+		}
+
+		for _, c := range e.alphabet {
+			next, ok := e.step(current.live, c)
+			if !ok {
+				return nil, false
+			}
+
+			nextKey := stateKey(next)
+			if seen[nextKey] {
+				continue
+			}
+
+			e.states++
+			if e.states > maxExploredStates {
+				return nil, false
+			}
+
+			seen[nextKey] = true
+			queue = append(queue, state{suffix: current.suffix + string([]byte{c}), live: next})
+		}
+	}
+
+	e.reachable[key] = reached
+
+	return reached, true
+}
+
+// step returns the patterns that can still match after reading c. It
+// returns false if that goes over maxExploredSteps.
+func (e *pathExplorer) step(live []livePattern, c byte) ([]livePattern, bool) {
+	e.steps += len(live)
+	if e.steps > maxExploredSteps {
+		return nil, false
+	}
+
+	next := make([]livePattern, 0, len(live))
+	for _, l := range live {
+		if states := step(e.patterns[l.pattern], l.states, c); len(states) > 0 {
+			next = append(next, livePattern{pattern: l.pattern, states: states})
+		}
+	}
+
+	return next, true
+}
+
+// matches returns an id for the set of patterns that have matched
+func (e *pathExplorer) matches(live []livePattern) int {
+	var sb strings.Builder
+	for _, l := range live {
+		if n := len(l.states); l.states[n-1] == len(e.patterns[l.pattern]) {
+			sb.WriteString(strconv.Itoa(l.pattern) + ",")
+		}
+	}
+
+	id, ok := e.matchSets[sb.String()]
+	if !ok {
+		id = len(e.matchSets)
+		e.matchSets[sb.String()] = id
+	}
+
+	return id
+}
+
+// add records prefix followed by suffix as a path matching the plain
+// rule and patterns if it is the shortest found
+func (e *pathExplorer) add(terminal, matches int, prefix, suffix string) {
+	key := [2]int{terminal, matches}
+	if path, ok := e.shortest[key]; ok && len(path) <= len(prefix)+len(suffix) {
+		return
+	}
+
+	e.shortest[key] = prefix + suffix
+}
+
+// livePattern is the positions of a pattern that can still match
+type livePattern struct {
+	pattern int
+	states  patternStates
+}
+
+func stateKey(live []livePattern) string {
+	var key []byte
+	for _, l := range live {
+		key = strconv.AppendInt(key, int64(l.pattern), 10)
+		key = append(key, ':')
+		for _, pos := range l.states {
+			key = strconv.AppendInt(key, int64(pos), 10)
+			key = append(key, ',')
+		}
+		key = append(key, ';')
+	}
+
+	return string(key)
+}
+
+// fillerByte returns a byte that is not used
+func fillerByte(used map[byte]bool) byte {
+	for _, c := range []byte("xyzqjkwvabcdefghilmnoprstu0123456789") {
+		if !used[c] {
+			return c
+		}
+	}
+
+	for c := byte(0x7F); c > 0; c-- {
+		if !used[c] {
+			return c
+		}
+	}
+
+	return 0xFF
+}
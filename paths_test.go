@@ -0,0 +1,67 @@
+package robotstxt
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPathPattern_matchesLikeGlob(t *testing.T) {
+	values := []string{
+		"/", "/a", "/a*", "/a$", "/*", "*", "$", "/*$", "/a*b", "/a*b$",
+		"/a**b", "/a%2Ab", "/a*%2A", "/a%24", "/a*%24", "/a*%2524",
+		"/a$b", "/a*$b", "/%2A*", "/a?b*", "/ab*ab*$", "/aa*a$",
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	alphabet := []byte("/ab*$?%2A4")
+
+	for _, value := range values {
+		r := newRule(value, false)
+
+		pattern := newPathPattern(r)
+
+		for i := 0; i < 2000; i++ {
+			path := make([]byte, 1+rnd.Intn(8))
+			path[0] = '/'
+			for j := 1; j < len(path); j++ {
+				path[j] = alphabet[rnd.Intn(len(alphabet))]
+			}
+
+			if expected, actual := r.matches(string(path)), pattern.matches(string(path)); expected != actual {
+				t.Errorf("Expected %q matching %q to be %v, got %v", value, path, expected, actual)
+			}
+		}
+	}
+}
+
+func TestExplorePaths_everyCombination(t *testing.T) {
+	var rules []*rule
+	for _, value := range []string{"/a", "/*b", "/a*c$"} {
+		r := newRule(value, false)
+		rules = append(rules, r)
+	}
+
+	combinations := make(map[[3]bool]bool)
+	complete := explorePaths(rules, func(path string) bool {
+		var matched [3]bool
+		for i, r := range rules {
+			matched[i] = r.matches(path)
+		}
+
+		if combinations[matched] {
+			t.Errorf("Expected each combination once, got %v again for %q", matched, path)
+		}
+		combinations[matched] = true
+
+		return true
+	})
+
+	if !complete {
+		t.Errorf("Expected every path to be explored")
+	}
+
+	// Every combination is possible except /a*c$ without /a
+	if len(combinations) != 6 {
+		t.Errorf("Expected 6 combinations, got %v", combinations)
+	}
+}
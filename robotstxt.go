@@ -6,10 +6,14 @@
 package robotstxt
 
 import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
 	"net/url"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/net/idna"
@@ -18,20 +22,84 @@ import (
 type rule struct {
 	isAllowed bool
 	path      string
-	pattern   *regexp.Regexp
+	pattern   *glob
+	value     string
+	line      int
+	text      string
+}
+
+// Precedence is the strategy used to pick between multiple rules
+// that match the same path
+type Precedence int
+
+const (
+	// LongestMatch follows RFC 9309: the most specific rule, measured by
+	// the length of its path in octets, takes precedence for both plain
+	// and wildcard rules. If an Allow and a Disallow rule are equally
+	// specific the Allow rule wins.
+	LongestMatch Precedence = iota
+
+	// LegacyOrder is the behaviour of earlier versions of this package:
+	// the first matching wildcard rule takes precedence, otherwise the
+	// longest matching plain path wins.
+	LegacyOrder
+)
+
+// DefaultMaxBytes is the number of bytes ParseReader will read before
+// ignoring the rest of the file. It is the minimum RFC 9309 requires
+// crawlers to parse.
+const DefaultMaxBytes = 500 * 1024
+
+type options struct {
+	precedence        Precedence
+	maxBytes          int64
+	userAgentMatching UserAgentMatching
+	fallbacks         map[string][]string
+}
+
+// Option configures how a robots.txt file is parsed and matched
+type Option func(*options)
+
+// WithMaxBytes sets the maximum number of bytes to parse. Anything
+// after the last complete line within the limit is ignored and the
+// result is marked as truncated. Defaults to DefaultMaxBytes for
+// ParseReader and no limit for Parse.
+func WithMaxBytes(maxBytes int64) Option {
+	return func(o *options) {
+		o.maxBytes = maxBytes
+	}
+}
+
+// WithPrecedence sets the strategy used to resolve conflicting rules.
+// Defaults to LongestMatch.
+func WithPrecedence(precedence Precedence) Option {
+	return func(o *options) {
+		o.precedence = precedence
+	}
 }
 
 type group struct {
 	rules      []*rule
 	crawlDelay time.Duration
+	line       int
+	indexOnce  sync.Once
+	index      *ruleIndex
 }
 
 // RobotsTxt represents a parsed robots.txt file
 type RobotsTxt struct {
-	url      *url.URL
-	groups   map[string]*group
-	sitemaps []string
-	host     string
+	url         *url.URL
+	groups      map[string]*group
+	sitemaps    []string
+	host        string
+	precedence  Precedence
+	matching    UserAgentMatching
+	fallbacks   map[string][]string
+	records     []*record
+	directives  []Directive
+	diagnostics []Diagnostic
+	truncated   bool
+	fetch       *FetchInfo
 }
 
 // InvalidHostError is the error when a URL is tested with IsAllowed that
@@ -51,6 +119,92 @@ func parseAndNormalizeURL(urlStr string) (u *url.URL, err error) {
 	return
 }
 
+// matchPath returns the path and query of the URL in the same
+// unescaped form that rule paths are stored in
+func matchPath(u *url.URL) string {
+	// RawPath is only set if the path was not escaped the default way
+	path := u.RawPath
+	if path == "" {
+		path = u.EscapedPath()
+	}
+
+	if unescapedPath, err := unescapePath(path); err == nil {
+		path = unescapedPath
+	} else {
+		path = u.Path
+	}
+
+	if path == "" {
+		path = "/"
+	}
+
+	if u.RawQuery != "" || u.ForceQuery {
+		query := u.RawQuery
+		if unescapedQuery, err := unescapePath(query); err == nil {
+			query = unescapedQuery
+		}
+
+		path += "?" + query
+	}
+
+	return path
+}
+
+// unescapePath is like url.PathUnescape but keeps the characters that
+// split up a path and query, ?, &, = and #, escaped so that an escaped
+// delimiter does not match a real one. They are kept with upper case
+// hex digits so the same character is always escaped the same way.
+func unescapePath(s string) (string, error) {
+	if strings.IndexByte(s, '%') < 0 {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			sb.WriteByte(s[i])
+			continue
+		}
+
+		if i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			end := i + 3
+			if end > len(s) {
+				end = len(s)
+			}
+
+			return "", url.EscapeError(s[i:end])
+		}
+
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if strings.IndexByte("?&=#", c) > -1 {
+			sb.WriteString(strings.ToUpper(s[i : i+3]))
+		} else {
+			sb.WriteByte(c)
+		}
+
+		i += 2
+	}
+
+	return sb.String(), nil
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	}
+
+	return c - '0'
+}
+
 func replaceSuffix(str, suffix, replacement string) string {
 	if strings.HasSuffix(str, suffix) {
 		return str[:len(str)-len(suffix)] + replacement
@@ -63,19 +217,6 @@ func isPattern(path string) bool {
 	return strings.IndexRune(path, '*') > -1 || strings.HasSuffix(path, "$")
 }
 
-func compilePattern(pattern string) (*regexp.Regexp, error) {
-	pattern = regexp.QuoteMeta(pattern)
-	pattern = strings.Replace(pattern, "\\*", "(?:.*)", -1)
-
-	pattern = replaceSuffix(pattern, "\\$", "$")
-	pattern = replaceSuffix(pattern, "%24", "\\$")
-	pattern = replaceSuffix(pattern, "%2524", "%24")
-
-	pattern = strings.Replace(pattern, "%2A", "\\*", -1)
-
-	return regexp.Compile(pattern)
-}
-
 func normaliseUserAgent(userAgent string) string {
 	index := strings.IndexRune(userAgent, '/')
 	if index > -1 {
@@ -85,101 +226,544 @@ func normaliseUserAgent(userAgent string) string {
 	return strings.ToLower(strings.TrimSpace(userAgent))
 }
 
-func (r *group) isAllowed(userAgent string, path string) bool {
-	var result = true
-	var resultPathLength = 0
+func (r *rule) matches(path string) bool {
+	if r.pattern != nil {
+		return r.pattern.match(path)
+	}
 
-	for _, rule := range r.rules {
-		if rule.pattern != nil {
-			// The first matching pattern takes precedence
-			if rule.pattern.MatchString(path) {
-				return rule.isAllowed
+	return strings.HasPrefix(path, r.path)
+}
+
+func (r *group) isAllowed(path string, precedence Precedence) bool {
+	rule, _ := r.match(path, precedence, nil)
+	return rule == nil || rule.isAllowed
+}
+
+// match returns the rule that decides if the path is allowed, or nil if
+// no rule matches, and why it was chosen. If matched is not nil it is
+// called with every rule that matches the path.
+func (r *group) match(path string, precedence Precedence, matched func(*rule)) (*rule, Reason) {
+	var i int
+	var reason Reason
+
+	if index := r.ruleIndex(); index != nil && matched == nil {
+		i, reason = index.match(r, path, precedence)
+	} else {
+		i, reason = r.matchRules(path, precedence, matched)
+	}
+
+	if i < 0 {
+		return nil, reason
+	}
+
+	return r.rules[i], reason
+}
+
+// matchRules is match without the index, checking every rule in order
+func (r *group) matchRules(path string, precedence Precedence, matched func(*rule)) (int, Reason) {
+	if matched == nil {
+		return matchRuleSet(r, path, precedence, nil)
+	}
+
+	return matchRuleSet(r, path, precedence, func(i int) {
+		matched(r.rules[i])
+	})
+}
+
+func (r *group) ruleCount() int                      { return len(r.rules) }
+func (r *group) rulePath(i int) string               { return r.rules[i].path }
+func (r *group) ruleAllowed(i int) bool              { return r.rules[i].isAllowed }
+func (r *group) ruleWildcard(i int) bool             { return r.rules[i].pattern != nil }
+func (r *group) ruleMatches(i int, path string) bool { return r.rules[i].matches(path) }
+
+// ruleSet is the rules of a group in file order
+type ruleSet interface {
+	ruleCount() int
+	rulePath(i int) string
+	ruleAllowed(i int) bool
+	ruleWildcard(i int) bool
+	ruleMatches(i int, path string) bool
+}
+
+// matchRuleSet returns the index of the rule that decides if the path
+// is allowed, or -1 if no rule matches, and why it was chosen. If
+// matched is not nil it is called with the index of every rule that
+// matches the path.
+func matchRuleSet(rules ruleSet, path string, precedence Precedence, matched func(int)) (int, Reason) {
+	if precedence == LegacyOrder {
+		return matchRuleSetLegacy(rules, path, matched)
+	}
+
+	result := -1
+	reason := ReasonNoMatch
+
+	for i, n := 0, rules.ruleCount(); i < n; i++ {
+		if !rules.ruleMatches(i, path) {
+			continue
+		}
+
+		if matched != nil {
+			matched(i)
+		}
+
+		// The longest matching rule takes precedence with
+		// allow winning if the lengths are equal
+		length := len(rules.rulePath(i))
+		if result < 0 || length > len(rules.rulePath(result)) {
+			result, reason = i, ReasonLongestMatch
+		} else if length == len(rules.rulePath(result)) && rules.ruleAllowed(i) != rules.ruleAllowed(result) {
+			if rules.ruleAllowed(i) {
+				result = i
 			}
-		} else {
-			// The longest matching path takes precedence
-			if resultPathLength > len(rule.path) {
-				continue
+			reason = ReasonAllowWinsTie
+		}
+	}
+
+	return result, reason
+}
+
+func matchRuleSetLegacy(rules ruleSet, path string, matched func(int)) (int, Reason) {
+	result := -1
+	reason := ReasonNoMatch
+	firstPattern := -1
+
+	for i, n := 0, rules.ruleCount(); i < n; i++ {
+		if !rules.ruleMatches(i, path) {
+			continue
+		}
+
+		if matched != nil {
+			matched(i)
+		}
+
+		if rules.ruleWildcard(i) {
+			// The first matching pattern takes precedence
+			if firstPattern < 0 {
+				firstPattern = i
 			}
 
-			if strings.HasPrefix(path, rule.path) {
-				result = rule.isAllowed
-				resultPathLength = len(rule.path)
+			if matched == nil {
+				break
 			}
+
+			continue
 		}
+
+		// The longest matching path takes precedence
+		if result < 0 || len(rules.rulePath(i)) >= len(rules.rulePath(result)) {
+			result, reason = i, ReasonLongestMatch
+		}
+	}
+
+	if firstPattern > -1 {
+		return firstPattern, ReasonFirstPattern
 	}
 
-	return result
+	return result, reason
 }
 
 // Parse parses the contents or a robots.txt file and returns a
 // RobotsTxt struct that can be used to check if URLs can be crawled
 // or extract crawl delays, sitemaps or the preferred host name
-func Parse(contents string, urlStr string) (robotsTxt *RobotsTxt, err error) {
-	u, err := parseAndNormalizeURL(urlStr)
+func Parse(contents string, urlStr string, opts ...Option) (robotsTxt *RobotsTxt, err error) {
+	p, err := newParser(urlStr, opts)
 	if err != nil {
 		return
 	}
 
-	robotsTxt = &RobotsTxt{
-		url:    u,
-		groups: make(map[string]*group),
+	p.parseString(contents, p.options.maxBytes)
+
+	return p.robotsTxt, nil
+}
+
+// ParseReader is like Parse but reads the robots.txt file line by line
+// from r, stopping after DefaultMaxBytes or the limit set with
+// WithMaxBytes. Use Truncated to check if the limit was reached.
+func ParseReader(r io.Reader, urlStr string, opts ...Option) (robotsTxt *RobotsTxt, err error) {
+	p, err := newParser(urlStr, opts)
+	if err != nil {
+		return
 	}
 
-	var userAgents []string
-	isNoneUserAgentState := false
+	maxBytes := p.options.maxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
 
-	lines := strings.Split(contents, "\n")
-	for _, line := range lines {
-		parts := strings.SplitN(line, ":", 2)
-		if len(parts) > 1 {
-			rule, val := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if err = p.parse(r, maxBytes); err != nil {
+		return nil, err
+	}
 
-			switch strings.ToLower(rule) {
-			case "user-agent":
-				if isNoneUserAgentState {
-					userAgents = nil
-				}
-				userAgents = append(userAgents, normaliseUserAgent(val))
-				break
-			case "allow":
-				for _, ua := range userAgents {
-					robotsTxt.addPathRule(ua, val, true)
-				}
-				break
-			case "disallow":
-				for _, ua := range userAgents {
-					robotsTxt.addPathRule(ua, val, false)
-				}
-				break
-			case "crawl-delay":
-				for _, ua := range userAgents {
-					robotsTxt.addCrawlDelay(ua, val)
-				}
-				break
-			case "sitemap":
-				if val != "" {
-					robotsTxt.sitemaps = append(robotsTxt.sitemaps, val)
-				}
-				break
-			case "host":
-				if val != "" {
-					robotsTxt.host = val
-				}
-				break
+	return p.robotsTxt, nil
+}
+
+type parser struct {
+	robotsTxt            *RobotsTxt
+	options              options
+	record               *record
+	isNoneUserAgentState bool
+	lineNumber           int
+}
+
+func newParser(urlStr string, opts []Option) (*parser, error) {
+	u, err := parseAndNormalizeURL(urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &parser{
+		robotsTxt: &RobotsTxt{
+			url:        u,
+			groups:     make(map[string]*group),
+			precedence: o.precedence,
+			matching:   o.userAgentMatching,
+			fallbacks:  o.fallbacks,
+		},
+		options: o,
+	}, nil
+}
+
+// parse reads lines from r until EOF or until maxBytes have been
+// read. A maxBytes of 0 or less means there is no limit.
+func (p *parser) parse(r io.Reader, maxBytes int64) error {
+	// Read one byte past the limit to detect truncation. There is
+	// nothing past the largest limit so it does not need checking.
+	if maxBytes > 0 && maxBytes < math.MaxInt64 {
+		r = io.LimitReader(r, maxBytes+1)
+	}
+
+	maxLineLength := math.MaxInt32
+	if maxBytes > 0 && maxBytes < math.MaxInt32-2 {
+		maxLineLength = int(maxBytes) + 2
+	}
+
+	var advance int
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineLength)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		n, token, err := scanLines(data, atEOF)
+		advance = n
+		return n, token, err
+	})
+
+	read := int64(0)
+	for scanner.Scan() {
+		if !p.parseNextLine(scanner.Text(), advance, &read, maxBytes) {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseString is parse for contents that are already in memory. Lines
+// are sliced from contents rather than copied.
+func (p *parser) parseString(contents string, maxBytes int64) {
+	// Only look one byte past the limit like parse
+	if maxBytes > 0 && int64(len(contents))-1 > maxBytes {
+		contents = contents[:maxBytes+1]
+	}
+
+	read := int64(0)
+	for contents != "" {
+		line, advance := nextLine(contents)
+		contents = contents[advance:]
+
+		if !p.parseNextLine(line, advance, &read, maxBytes) {
+			return
+		}
+	}
+}
+
+// parseNextLine parses the next line which was advance bytes long with
+// its line ending. read is the number of bytes read before the line.
+// It returns false if the line crosses maxBytes and parsing should stop.
+func (p *parser) parseNextLine(line string, advance int, read *int64, maxBytes int64) bool {
+	// Only the first byte of the line ending needs to be within
+	// the limit for the line to be complete
+	end := *read + int64(len(line))
+	if advance > len(line) {
+		end++
+	}
+	*read += int64(advance)
+
+	if maxBytes > 0 && end > maxBytes {
+		// The line crossing the limit is incomplete so is
+		// ignored rather than risk misreading it
+		p.robotsTxt.truncated = true
+		p.lineNumber++
+		p.addDiagnostic(1, SeverityWarning, "", CodeTruncated,
+			"file exceeds the size limit, the rest is ignored")
+		return false
+	}
+
+	if p.lineNumber == 0 {
+		line = strings.TrimPrefix(line, byteOrderMark)
+	}
+
+	p.parseLine(line)
+
+	return true
+}
+
+// nextLine returns the first line of s, split the same way as
+// scanLines, and the length of the line with its line ending
+func nextLine(s string) (string, int) {
+	i := strings.IndexAny(s, "\r\n")
+	switch {
+	case i < 0:
+		return s, len(s)
+	case s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n':
+		return s[:i], i + 2
+	}
+
+	return s[:i], i + 1
+}
+
+const byteOrderMark = "\uFEFF"
+
+// scanLines is a bufio.SplitFunc that splits on \n, \r\n and \r
+// line endings
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+
+		// Need the next byte to tell \r from \r\n
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
 			}
 
-			isNoneUserAgentState = strings.ToLower(rule) != "user-agent"
+			return i + 1, data[:i], nil
 		}
+
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+
+		return 0, nil, nil
 	}
 
-	return
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+// userAgentLine is a user-agent line in a record
+type userAgentLine struct {
+	name string
+	line int
+}
+
+// record is a group as it appears in the file: one or more user-agent
+// lines followed by the rules that apply to them
+type record struct {
+	userAgents    []userAgentLine
+	rules         []*rule
+	crawlDelay    time.Duration
+	hasCrawlDelay bool
+}
+
+func (p *parser) parseLine(line string) {
+	p.lineNumber++
+	text := strings.TrimSpace(line)
+
+	// Comments can start anywhere on a line
+	if index := strings.IndexByte(line, '#'); index > -1 {
+		line = line[:index]
+	}
+
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return
+	}
+
+	column := strings.Index(line, trimmed) + 1
+
+	parts := strings.SplitN(line, ":", 2)
+	rule := strings.TrimSpace(parts[0])
+	if len(parts) < 2 || rule == "" {
+		p.addDiagnostic(column, SeverityError, "", CodeInvalidLine,
+			"line is not a valid directive")
+		return
+	}
+
+	val := strings.TrimSpace(parts[1])
+	valColumn := len(parts[0]) + 2
+	if val != "" {
+		valColumn += strings.Index(parts[1], val)
+	}
+
+	robotsTxt := p.robotsTxt
+	directive := strings.ToLower(rule)
+
+	robotsTxt.directives = append(robotsTxt.directives, Directive{
+		Name:  directive,
+		Value: val,
+		Line:  p.lineNumber,
+	})
+
+	switch directive {
+	case "user-agent":
+		if p.isNoneUserAgentState || p.record == nil {
+			p.record = &record{}
+			robotsTxt.records = append(robotsTxt.records, p.record)
+		}
+		if val == "" {
+			p.addDiagnostic(valColumn, SeverityWarning, directive, CodeEmptyValue,
+				"user-agent has no value")
+		}
+		p.record.userAgents = append(p.record.userAgents, userAgentLine{
+			name: normaliseUserAgent(val),
+			line: p.lineNumber,
+		})
+		break
+	case "allow", "disallow":
+		if !p.checkInGroup(column, directive) {
+			break
+		}
+		path := val
+		if normalised, code, message := normaliseRuleValue(val); code != "" {
+			p.addDiagnostic(valColumn, SeverityWarning, directive, code, message)
+			path = normalised
+		}
+		rule := newRule(path, directive == "allow")
+		if rule != nil {
+			rule.value = val
+			rule.line = p.lineNumber
+			rule.text = text
+			p.record.rules = append(p.record.rules, rule)
+		}
+		for _, ua := range p.record.userAgents {
+			robotsTxt.addRule(ua, rule)
+		}
+		break
+	case "crawl-delay":
+		if !p.checkInGroup(column, directive) {
+			break
+		}
+		// Invalid delays still mean the group exists
+		for _, ua := range p.record.userAgents {
+			robotsTxt.getGroup(ua)
+		}
+		// ParseFloat accepts NaN and infinities and the delay must
+		// fit in a time.Duration
+		delay, err := strconv.ParseFloat(val, 64)
+		if err != nil || math.IsNaN(delay) || delay < 0 || delay*float64(time.Second) >= math.MaxInt64 {
+			p.addDiagnostic(valColumn, SeverityWarning, directive, CodeInvalidCrawlDelay,
+				"crawl-delay is not a valid number of seconds")
+			break
+		}
+		p.record.crawlDelay = time.Duration(delay * float64(time.Second))
+		p.record.hasCrawlDelay = true
+		for _, ua := range p.record.userAgents {
+			robotsTxt.getGroup(ua).crawlDelay = p.record.crawlDelay
+		}
+		break
+	case "sitemap":
+		if val != "" {
+			robotsTxt.sitemaps = append(robotsTxt.sitemaps, val)
+		} else {
+			p.addDiagnostic(valColumn, SeverityWarning, directive, CodeEmptyValue,
+				"sitemap has no value")
+		}
+		break
+	case "host":
+		if val != "" {
+			robotsTxt.host = val
+		} else {
+			p.addDiagnostic(valColumn, SeverityWarning, directive, CodeEmptyValue,
+				"host has no value")
+		}
+		break
+	default:
+		p.addDiagnostic(column, SeverityWarning, directive, CodeUnknownDirective,
+			"unknown directive "+rule)
+	}
+
+	p.isNoneUserAgentState = directive != "user-agent"
 }
 
-func (r *RobotsTxt) addPathRule(userAgent string, path string, isAllowed bool) error {
-	g, ok := r.groups[userAgent]
+// checkInGroup records a diagnostic if a group member is
+// not preceded by a user-agent line
+func (p *parser) checkInGroup(column int, directive string) bool {
+	if p.record != nil {
+		return true
+	}
+
+	p.addDiagnostic(column, SeverityWarning, directive, CodeOutsideGroup,
+		directive+" is not in a group and will be ignored")
+
+	return false
+}
+
+func (p *parser) addDiagnostic(column int, severity Severity, directive string, code DiagnosticCode, message string) {
+	p.robotsTxt.diagnostics = append(p.robotsTxt.diagnostics, Diagnostic{
+		Line:      p.lineNumber,
+		Column:    column,
+		Severity:  severity,
+		Directive: directive,
+		Code:      code,
+		Message:   message,
+	})
+}
+
+// normaliseRuleValue rewrites allow and disallow values that are not
+// valid paths the same way Google's parser does. If the value was
+// rewritten the code and message describe why.
+func normaliseRuleValue(value string) (normalised string, code DiagnosticCode, message string) {
+	switch {
+	case value == "":
+		return "", CodeEmptyValue, "rule has no value and will be ignored"
+	case strings.HasPrefix(value, "/") || strings.HasPrefix(value, "*"):
+		return value, "", ""
+	}
+
+	// Absolute URLs are reduced to their path and query
+	if index := strings.Index(value, "://"); index > 0 && !strings.ContainsAny(value[:index], "/?*$") {
+		normalised = "/"
+		if pathIndex := strings.IndexAny(value[index+3:], "/?"); pathIndex > -1 {
+			normalised = value[index+3+pathIndex:]
+			if strings.HasPrefix(normalised, "?") {
+				normalised = "/" + normalised
+			}
+		}
+
+		return normalised, CodeAbsoluteURL, "absolute URL rewritten to " + normalised
+	}
+
+	normalised = "/" + value
+
+	return normalised, CodeRelativePath, "relative path rewritten to " + normalised
+}
+
+func (r *RobotsTxt) getGroup(userAgent userAgentLine) *group {
+	g, ok := r.groups[userAgent.name]
 	if !ok {
-		g = &group{}
-		r.groups[userAgent] = g
+		g = &group{line: userAgent.line}
+		r.groups[userAgent.name] = g
+	}
+
+	return g
+}
+
+// newRule returns the rule for the path or nil if the path is empty
+func newRule(path string, isAllowed bool) *rule {
+	if path == "" {
+		return nil
 	}
 
 	isPattern := isPattern(path)
@@ -189,44 +773,39 @@ func (r *RobotsTxt) addPathRule(userAgent string, path string, isAllowed bool) e
 
 	// Keep * escaped
 	path = strings.Replace(path, "%2A", "%252A", -1)
-	if unescapedPath, err := url.PathUnescape(path); err == nil {
+	if unescapedPath, err := unescapePath(path); err == nil {
 		path = unescapedPath
 	} else {
 		path = strings.Replace(path, "%252A", "%2A", -1)
 	}
 
 	if isPattern {
-		regexPattern, err := compilePattern(path)
-		if err != nil {
-			return err
-		}
-
-		g.rules = append(g.rules, &rule{
-			pattern:   regexPattern,
-			isAllowed: isAllowed,
-		})
-	} else {
-		g.rules = append(g.rules, &rule{
+		return &rule{
 			path:      path,
+			pattern:   compileGlob(path),
 			isAllowed: isAllowed,
-		})
+		}
 	}
 
-	return nil
-}
-
-func (r *RobotsTxt) addCrawlDelay(userAgent string, crawlDelay string) (err error) {
-	g, ok := r.groups[userAgent]
-	if !ok {
-		g = &group{}
-		r.groups[userAgent] = g
+	return &rule{
+		path:      path,
+		isAllowed: isAllowed,
 	}
+}
 
-	if delay, err := strconv.ParseFloat(crawlDelay, 64); err == nil {
-		g.crawlDelay = time.Duration(delay * float64(time.Second))
+// addRule adds the rule to the user agent's group. Empty rules, which
+// are nil, match nothing but still mean the group has rules.
+func (r *RobotsTxt) addRule(userAgent userAgentLine, rule *rule) {
+	g := r.getGroup(userAgent)
+	if rule != nil {
+		g.rules = append(g.rules, rule)
 	}
+}
 
-	return
+// Truncated returns true if the robots.txt file was larger than the
+// size limit and only the start of it was parsed
+func (r *RobotsTxt) Truncated() bool {
+	return r.truncated
 }
 
 // Host is the preferred hosts from the robots.txt file if there is one
@@ -237,11 +816,7 @@ func (r *RobotsTxt) Host() string {
 // CrawlDelay returns the crawl delay for the specified
 // user agent or 0 if there is none
 func (r *RobotsTxt) CrawlDelay(userAgent string) time.Duration {
-	if group, ok := r.groups[normaliseUserAgent(userAgent)]; ok {
-		return group.crawlDelay
-	}
-
-	if group, ok := r.groups["*"]; ok {
+	if _, group := r.findGroup(userAgent); group != nil {
 		return group.crawlDelay
 	}
 
@@ -253,7 +828,10 @@ func (r *RobotsTxt) Sitemaps() []string {
 	return r.sitemaps
 }
 
-// IsAllowed checks if the specified path is allowed by the robots.txt file
+// IsAllowed checks if the specified URL is allowed by the robots.txt file.
+// Rules are matched against the path and query string of the URL.
+// Escaped ?, &, = and # characters only match the same escaped
+// characters in rules, not the delimiters they stand for.
 func (r *RobotsTxt) IsAllowed(userAgent string, urlStr string) (result bool, err error) {
 	u, err := parseAndNormalizeURL(urlStr)
 	if err != nil {
@@ -266,11 +844,10 @@ func (r *RobotsTxt) IsAllowed(userAgent string, urlStr string) (result bool, err
 	}
 
 	result = true
+	path := matchPath(u)
 
-	if group, ok := r.groups[normaliseUserAgent(userAgent)]; ok {
-		result = group.isAllowed(userAgent, u.Path)
-	} else if group, ok := r.groups["*"]; ok {
-		result = group.isAllowed(userAgent, u.Path)
+	if _, group := r.findGroup(userAgent); group != nil {
+		result = group.isAllowed(path, r.precedence)
 	}
 
 	return
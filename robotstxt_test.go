@@ -1,13 +1,17 @@
 package robotstxt
 
 import (
+	"errors"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 )
 
-func testRobots(t *testing.T, contents string, url string, allowed []string, disallowed []string) {
-	robots, _ := Parse(contents, url)
+func testRobots(t *testing.T, contents string, url string, allowed []string, disallowed []string, opts ...Option) {
+	robots, _ := Parse(contents, url, opts...)
 
 	for _, path := range allowed {
 		allowed, err := robots.IsAllowed("*", path)
@@ -95,7 +99,75 @@ func TestRobotsTxt_correctOrderPresidenceForAllowAndDisallow(t *testing.T) {
 		"http://www.example.com/test",
 	}
 
-	testRobots(t, contents, url, allowed, disallowed)
+	testRobots(t, contents, url, allowed, disallowed, WithPrecedence(LegacyOrder))
+}
+
+func TestRobotsTxt_longestMatchPrecedence(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+
+	tests := []struct {
+		contents   string
+		allowed    []string
+		disallowed []string
+	}{
+		{
+			contents: `
+				User-agent: *
+				Disallow: /fish*.php
+				Allow: /fish/index.php
+			`,
+			allowed:    []string{"http://www.example.com/fish/index.php"},
+			disallowed: []string{"http://www.example.com/fish.php"},
+		},
+		{
+			contents: `
+				User-agent: *
+				Allow: /p
+				Disallow: /
+			`,
+			allowed:    []string{"http://www.example.com/page"},
+			disallowed: []string{"http://www.example.com/other"},
+		},
+		{
+			contents: `
+				User-agent: *
+				Allow: /folder
+				Disallow: /folder
+			`,
+			allowed: []string{"http://www.example.com/folder/page"},
+		},
+		{
+			contents: `
+				User-agent: *
+				Allow: /page
+				Disallow: /*.htm
+			`,
+			allowed:    []string{"http://www.example.com/page"},
+			disallowed: []string{"http://www.example.com/page.htm"},
+		},
+		{
+			contents: `
+				User-agent: *
+				Allow: /$
+				Disallow: /
+			`,
+			allowed:    []string{"http://www.example.com/"},
+			disallowed: []string{"http://www.example.com/page.htm"},
+		},
+		{
+			contents: `
+				User-agent: *
+				Disallow: /*/private
+				Allow: /*/private/public
+			`,
+			allowed:    []string{"http://www.example.com/a/private/public/page"},
+			disallowed: []string{"http://www.example.com/a/private/page"},
+		},
+	}
+
+	for _, test := range tests {
+		testRobots(t, test.contents, url, test.allowed, test.disallowed)
+	}
 }
 
 func TestRobotsTxt_ignoreRulesThatAreNotInAGroup(t *testing.T) {
@@ -641,3 +713,372 @@ func TestRobotsTxt_handleUrlEncodingsWithPatterns(t *testing.T) {
 
 	testRobots(t, contents, url, allowed, disallowed)
 }
+
+func TestRobotsTxt_matchRulesAgainstTheQueryString(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := `
+		User-agent: *
+		Disallow: /*?sessionid=
+		Disallow: /search?q=
+		Disallow: /*.php$
+		Disallow: /*&sort=
+		Disallow: /admin*.html
+	`
+
+	allowed := []string{
+		"http://www.example.com/search",
+		"http://www.example.com/search?page=1",
+		"http://www.example.com/index.php?id=1",
+		"http://www.example.com/page?redirect=/search?q=test",
+		"http://www.example.com/page?redirect=/admin/index.html",
+	}
+
+	disallowed := []string{
+		"http://www.example.com/?sessionid=1234",
+		"http://www.example.com/page?sessionid=1234",
+		"http://www.example.com/search?q=test",
+		"http://www.example.com/search?q=%E6%B5%8B%E8%AF%95",
+		"http://www.example.com/index.php",
+		"http://www.example.com/shop?colour=red&sort=price",
+		"http://www.example.com/admin/index.html",
+	}
+
+	testRobots(t, contents, url, allowed, disallowed)
+}
+
+func TestRobotsTxt_matchTheRootPathForUrlsWithoutAPath(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := `
+		User-agent: *
+		Disallow: /$
+	`
+
+	allowed := []string{
+		"http://www.example.com/index.html",
+	}
+
+	disallowed := []string{
+		"http://www.example.com",
+		"http://www.example.com/",
+	}
+
+	testRobots(t, contents, url, allowed, disallowed)
+}
+
+func TestRobotsTxt_parseFromAReader(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := `
+		User-agent: *
+		Disallow: /fish/
+		Sitemap: http://www.example.com/sitemap.xml
+	`
+
+	robots, err := ParseReader(iotest.OneByteReader(strings.NewReader(contents)), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if robots.Truncated() {
+		t.Errorf("Expected robots.txt to not be truncated")
+	}
+
+	allowed, _ := robots.IsAllowed("*", "http://www.example.com/fish/index.php")
+	if allowed {
+		t.Errorf("The path /fish/index.php should be disallowed")
+	}
+
+	if !reflect.DeepEqual(robots.Sitemaps(), []string{"http://www.example.com/sitemap.xml"}) {
+		t.Errorf("Expected sitemaps to match")
+	}
+}
+
+func TestRobotsTxt_stopParsingAtTheSizeLimit(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := "User-agent: *\n" +
+		"Disallow: /a\n" +
+		"Disallow: /b\n"
+
+	robots, _ := ParseReader(strings.NewReader(contents), url, WithMaxBytes(30))
+
+	if !robots.Truncated() {
+		t.Errorf("Expected robots.txt to be truncated")
+	}
+
+	allowed, _ := robots.IsAllowed("*", "http://www.example.com/a")
+	if allowed {
+		t.Errorf("The path /a should be disallowed")
+	}
+
+	// The partial "Disallow: /b" line must be ignored rather
+	// than parsed as "Disallow: /"
+	allowed, _ = robots.IsAllowed("*", "http://www.example.com/b")
+	if !allowed {
+		t.Errorf("The path /b should be allowed")
+	}
+
+	diagnostics := robots.Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Code != CodeTruncated || diagnostics[0].Line != 3 {
+		t.Errorf("Expected a truncated diagnostic on line 3, got %v", diagnostics)
+	}
+
+	robots, _ = ParseReader(strings.NewReader(contents), url, WithMaxBytes(int64(len(contents))))
+	if robots.Truncated() {
+		t.Errorf("Expected robots.txt exactly at the limit to not be truncated")
+	}
+}
+
+func TestRobotsTxt_largestSizeLimit(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := "User-agent: *\nDisallow: /a\n"
+
+	fromString, _ := Parse(contents, url, WithMaxBytes(math.MaxInt64))
+	fromReader, err := ParseReader(strings.NewReader(contents), url, WithMaxBytes(math.MaxInt64))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	for _, robots := range []*RobotsTxt{fromString, fromReader} {
+		if robots.Truncated() {
+			t.Errorf("Expected robots.txt not to be truncated")
+		}
+
+		if allowed, _ := robots.IsAllowed("*", "http://www.example.com/a"); allowed {
+			t.Errorf("The path /a should be disallowed")
+		}
+	}
+}
+
+func TestRobotsTxt_defaultSizeLimit(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := "User-agent: *\nDisallow: /a\n" +
+		strings.Repeat("# padding\n", DefaultMaxBytes/10) +
+		"Disallow: /b\n"
+
+	robots, _ := ParseReader(strings.NewReader(contents), url)
+	if !robots.Truncated() {
+		t.Errorf("Expected robots.txt to be truncated")
+	}
+
+	allowed, _ := robots.IsAllowed("*", "http://www.example.com/b")
+	if !allowed {
+		t.Errorf("The path /b should be allowed")
+	}
+
+	robots, _ = Parse(contents, url)
+	if robots.Truncated() {
+		t.Errorf("Expected Parse to not limit the size by default")
+	}
+}
+
+func TestRobotsTxt_returnReaderErrors(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	readErr := errors.New("read failed")
+
+	_, err := ParseReader(iotest.ErrReader(readErr), url)
+	if err != readErr {
+		t.Errorf("Expected the reader error to be returned, got %v", err)
+	}
+}
+
+func TestRobotsTxt_ignoreInlineComments(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := `
+		User-agent: * # all crawlers
+		Disallow: /private # keep out
+		Disallow: /w/   # Wikipedia style trailing comment
+		Allow: /private/public#no space before comment
+		Crawl-delay: 5 # seconds
+		Sitemap: http://www.example.com/sitemap.xml # main sitemap
+	`
+
+	allowed := []string{
+		"http://www.example.com/private/public/index.html",
+		"http://www.example.com/wiki/Main_Page",
+	}
+
+	disallowed := []string{
+		"http://www.example.com/private",
+		"http://www.example.com/private/index.html",
+		"http://www.example.com/w/index.php",
+	}
+
+	testRobots(t, contents, url, allowed, disallowed)
+
+	robots, _ := Parse(contents, url)
+
+	if robots.CrawlDelay("*") != 5*time.Second {
+		t.Errorf("Expected crawl delay to be 5")
+	}
+
+	if !reflect.DeepEqual(robots.Sitemaps(), []string{"http://www.example.com/sitemap.xml"}) {
+		t.Errorf("Expected sitemaps to match, got %v", robots.Sitemaps())
+	}
+
+	if len(robots.Diagnostics()) != 0 {
+		t.Errorf("Expected no diagnostics, got %v", robots.Diagnostics())
+	}
+}
+
+func TestRobotsTxt_ignoreLeadingByteOrderMark(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := "\uFEFFUser-agent: *\nDisallow: /fish/\n"
+
+	allowed := []string{
+		"http://www.example.com/fish",
+	}
+
+	disallowed := []string{
+		"http://www.example.com/fish/index.php",
+	}
+
+	testRobots(t, contents, url, allowed, disallowed)
+}
+
+func TestRobotsTxt_supportAllLineEndings(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	lines := []string{
+		"User-agent: *",
+		"Disallow: /fish/",
+		"Disallow: /test.html",
+		"",
+		"User-agent: b",
+		"Disallow: /b",
+	}
+
+	allowed := []string{
+		"http://www.example.com/fish",
+		"http://www.example.com/b",
+	}
+
+	disallowed := []string{
+		"http://www.example.com/fish/index.php",
+		"http://www.example.com/test.html",
+	}
+
+	for _, newline := range []string{"\n", "\r\n", "\r"} {
+		contents := strings.Join(lines, newline)
+		testRobots(t, contents, url, allowed, disallowed)
+
+		robots, _ := ParseReader(iotest.OneByteReader(strings.NewReader(contents)), url)
+		allowed, _ := robots.IsAllowed("b", "http://www.example.com/b")
+		if allowed {
+			t.Errorf("The path /b should be disallowed for b with %q line endings", newline)
+		}
+	}
+}
+
+func TestRobotsTxt_countCarriageReturnLinesTowardsTheSizeLimit(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := "User-agent: *\rDisallow: /a\rDisallow: /b\r"
+
+	robots, _ := ParseReader(strings.NewReader(contents), url, WithMaxBytes(30))
+
+	if !robots.Truncated() {
+		t.Errorf("Expected robots.txt to be truncated")
+	}
+
+	allowed, _ := robots.IsAllowed("*", "http://www.example.com/a")
+	if allowed {
+		t.Errorf("The path /a should be disallowed")
+	}
+
+	allowed, _ = robots.IsAllowed("*", "http://www.example.com/b")
+	if !allowed {
+		t.Errorf("The path /b should be allowed")
+	}
+}
+
+func TestRobotsTxt_normaliseRuleValues(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := `
+		User-agent: *
+		Disallow:
+		Disallow: private
+		Disallow: http://www.example.com/secret
+		Disallow: https://www.example.com?q=
+		Disallow: *.pdf
+	`
+
+	allowed := []string{
+		"http://www.example.com/",
+		"http://www.example.com/public",
+		"http://www.example.com/x/private",
+		"http://www.example.com/http://www.example.com/secret",
+	}
+
+	disallowed := []string{
+		"http://www.example.com/private",
+		"http://www.example.com/secret/page",
+		"http://www.example.com/?q=test",
+		"http://www.example.com/file.pdf",
+	}
+
+	testRobots(t, contents, url, allowed, disallowed)
+
+	robots, _ := Parse(contents, url)
+
+	var codes []DiagnosticCode
+	for _, diagnostic := range robots.Diagnostics() {
+		codes = append(codes, diagnostic.Code)
+	}
+
+	expected := []DiagnosticCode{CodeEmptyValue, CodeRelativePath, CodeAbsoluteURL, CodeAbsoluteURL}
+	if !reflect.DeepEqual(codes, expected) {
+		t.Errorf("Expected rewrites %v to be recorded, got %v", expected, robots.Diagnostics())
+	}
+}
+
+func TestRobotsTxt_emptyDisallowShouldAllowEverything(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := `
+		User-agent: *
+		Disallow: /
+
+		User-agent: b
+		Disallow:
+	`
+
+	robots, _ := Parse(contents, url)
+
+	allowed, _ := robots.IsAllowed("b", "http://www.example.com/page")
+	if !allowed {
+		t.Errorf("The path /page should be allowed for b")
+	}
+
+	allowed, _ = robots.IsAllowed("a", "http://www.example.com/page")
+	if allowed {
+		t.Errorf("The path /page should be disallowed for a")
+	}
+}
+
+func TestRobotsTxt_keepEscapedDelimitersEscaped(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := `
+		User-agent: *
+		Disallow: /a?b
+		Disallow: /*&sort=
+		Disallow: /c%3Fd
+		Disallow: /*%26e%3d
+		Disallow: /f%23g
+	`
+
+	allowed := []string{
+		"http://www.example.com/a%3Fb",
+		"http://www.example.com/page?q=a%26sort=1",
+		"http://www.example.com/c?d",
+		"http://www.example.com/page?x&e=1",
+		"http://www.example.com/f",
+	}
+
+	disallowed := []string{
+		"http://www.example.com/a?b",
+		"http://www.example.com/page?q=a&sort=1",
+		"http://www.example.com/c%3Fd",
+		"http://www.example.com/c%3fd",
+		"http://www.example.com/page?x%26e%3D1",
+		"http://www.example.com/f%23g",
+		"http://www.example.com/%61?b",
+	}
+
+	testRobots(t, contents, url, allowed, disallowed)
+}
@@ -0,0 +1,169 @@
+package robotstxt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// WriteTo writes the robots.txt file in a canonical form. User agents
+// with identical rules are grouped together, groups are ordered by user
+// agent and, unless LegacyOrder precedence is used, rules are sorted by
+// path. Parsing the output with the same options gives a RobotsTxt that
+// makes the same decisions.
+func (r *RobotsTxt) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+
+	bodies := make(map[string][]string)
+	var keys []string
+
+	for userAgent, group := range r.groups {
+		body := r.formatGroup(group)
+		if _, ok := bodies[body]; !ok {
+			keys = append(keys, body)
+		}
+		bodies[body] = append(bodies[body], userAgent)
+	}
+
+	for _, key := range keys {
+		sort.Strings(bodies[key])
+	}
+
+	// Order groups by their first user agent, * sorts first
+	sort.Slice(keys, func(i, j int) bool {
+		return bodies[keys[i]][0] < bodies[keys[j]][0]
+	})
+
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+
+		for _, userAgent := range bodies[key] {
+			buf.WriteString("User-agent: " + userAgent + "\n")
+		}
+
+		buf.WriteString(key)
+	}
+
+	if len(r.sitemaps) > 0 || r.host != "" {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+
+		for _, sitemap := range r.sitemaps {
+			buf.WriteString("Sitemap: " + sitemap + "\n")
+		}
+
+		if r.host != "" {
+			buf.WriteString("Host: " + r.host + "\n")
+		}
+	}
+
+	n, err := w.Write(buf.Bytes())
+
+	return int64(n), err
+}
+
+// String returns the robots.txt file in the canonical form used by WriteTo
+func (r *RobotsTxt) String() string {
+	var sb strings.Builder
+	r.WriteTo(&sb)
+
+	return sb.String()
+}
+
+// formatGroup returns the rules and crawl delay of the group
+func (r *RobotsTxt) formatGroup(g *group) string {
+	var sb strings.Builder
+
+	rules := make([]*rule, len(g.rules))
+	copy(rules, g.rules)
+
+	// Order only matters for wildcard rules using LegacyOrder
+	if r.precedence != LegacyOrder {
+		sort.SliceStable(rules, func(i, j int) bool {
+			if rules[i].path != rules[j].path {
+				return rules[i].path < rules[j].path
+			}
+
+			return rules[i].isAllowed && !rules[j].isAllowed
+		})
+	}
+
+	// Duplicates can be dropped once sorted but with LegacyOrder the
+	// last of equally long plain rules wins so every copy is kept
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		line := formatRule(rule)
+		if seen[line] && r.precedence != LegacyOrder {
+			continue
+		}
+
+		seen[line] = true
+		sb.WriteString(line + "\n")
+	}
+
+	if g.crawlDelay != 0 {
+		sb.WriteString("Crawl-delay: " + strconv.FormatFloat(g.crawlDelay.Seconds(), 'f', -1, 64) + "\n")
+	}
+
+	// Groups with no rules still stop the * group applying
+	if sb.Len() == 0 {
+		sb.WriteString("Disallow:\n")
+	}
+
+	return sb.String()
+}
+
+func formatRule(rule *rule) string {
+	directive := "Disallow: "
+	if rule.isAllowed {
+		directive = "Allow: "
+	}
+
+	return directive + escapeRulePath(rule.path, rule.pattern != nil)
+}
+
+// escapeRulePath is the reverse of the decoding done by newRule. It
+// returns a value that newRule will decode back to path.
+func escapeRulePath(path string, isPattern bool) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(path); {
+		c := path[i]
+
+		switch {
+		case c == '%' || c == '#' || c <= ' ' || c == 0x7F:
+			// % must be escaped as decoded paths may contain %2A and
+			// %24 which are treated specially when parsing
+			sb.WriteString(fmt.Sprintf("%%%02X", c))
+		case c == '*' && !isPattern:
+			// A * would make a plain path a pattern. It is escaped in
+			// lower case as newRule keeps %2A escaped.
+			sb.WriteString("%2a")
+		case c == '$' && i == len(path)-1 && !isPattern:
+			// A trailing $ would make a plain path a pattern
+			sb.WriteString("%24")
+		case c >= utf8.RuneSelf:
+			r, size := utf8.DecodeRuneInString(path[i:])
+			if r == utf8.RuneError && size == 1 {
+				sb.WriteString(fmt.Sprintf("%%%02X", c))
+			} else {
+				sb.WriteString(path[i : i+size])
+			}
+			i += size
+			continue
+		default:
+			sb.WriteByte(c)
+		}
+
+		i++
+	}
+
+	return sb.String()
+}
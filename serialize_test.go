@@ -0,0 +1,228 @@
+package robotstxt
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var roundTripContents = []string{
+	``,
+	`
+		User-agent: *
+		Disallow: /fish/
+		Disallow: /test.html
+		Allow: /fish/index.php
+		Crawl-delay: 1.5
+		Sitemap: http://www.example.com/sitemap.xml
+		Host: example.com
+	`,
+	`
+		User-agent: agEnTa
+		User-agent: agentb
+		Disallow: /fish
+		Disallow: /fish
+
+		User-agent: c
+		Disallow: /fish
+
+		User-agent: d
+		Disallow:
+
+		User-agent: e
+		Crawl-delay: 10
+	`,
+	`
+		User-agent: *
+		Disallow: /wiki:Article_wizard
+		Disallow: /wiki%3AArticle_wizard
+		Disallow: /اختبارات
+		Disallow: /%E6%B5%8B%E8%AF%95
+		Disallow: /%E0%A6%AA%E0%A6%B0%E0%A7%80
+		Disallow: /%FF%FE
+		Disallow: /%23hash%20space
+	`,
+	`
+		User-agent: *
+		Disallow: /%20%A/test
+		Disallow: /%24%A/test$
+		Disallow: /%B/*test%24
+		Disallow: /%20A/*test$
+		Disallow: /%20B/*test%24
+		Disallow: /%20C/test%24
+		Disallow: /%20D/%2Atest$
+		Disallow: /%252A/literal
+		Disallow: /%2525/*
+		Disallow: /a%2ab
+		Disallow: /b%2a%24
+		Allow: /price$/
+	`,
+	`
+		User-agent: *
+		Disallow: /*?sessionid=
+		Disallow: /*.php$
+		Allow: /*/public
+	`,
+}
+
+// assertSameDecisions checks both files have the same groups and rules
+func assertSameDecisions(t *testing.T, expected, actual *RobotsTxt) {
+	t.Helper()
+
+	if len(expected.groups) != len(actual.groups) {
+		t.Errorf("Expected %d groups, got %d", len(expected.groups), len(actual.groups))
+	}
+
+	for userAgent, expectedGroup := range expected.groups {
+		actualGroup, ok := actual.groups[userAgent]
+		if !ok {
+			t.Errorf("Expected a group for %q", userAgent)
+			continue
+		}
+
+		if expectedGroup.crawlDelay != actualGroup.crawlDelay {
+			t.Errorf("Expected crawl delay %v for %q, got %v", expectedGroup.crawlDelay, userAgent, actualGroup.crawlDelay)
+		}
+
+		if !reflect.DeepEqual(ruleKeys(expectedGroup.rules), ruleKeys(actualGroup.rules)) {
+			t.Errorf("Expected rules %v for %q, got %v", ruleKeys(expectedGroup.rules), userAgent, ruleKeys(actualGroup.rules))
+		}
+	}
+
+	if !reflect.DeepEqual(expected.Sitemaps(), actual.Sitemaps()) {
+		t.Errorf("Expected sitemaps %v, got %v", expected.Sitemaps(), actual.Sitemaps())
+	}
+
+	if expected.Host() != actual.Host() {
+		t.Errorf("Expected host %q, got %q", expected.Host(), actual.Host())
+	}
+}
+
+// ruleKeys returns a sorted, de-duplicated description of the rules
+func ruleKeys(rules []*rule) []string {
+	seen := make(map[string]bool)
+	var keys []string
+
+	for _, rule := range rules {
+		key := rule.path + " " + strconv.FormatBool(rule.isAllowed)
+		if rule.pattern != nil {
+			key += " " + newPathPattern(rule).key()
+		}
+
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func TestRobotsTxt_roundTrip(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+
+	for _, contents := range roundTripContents {
+		original, _ := Parse(contents, url)
+		text := original.String()
+
+		parsed, _ := Parse(text, url)
+		assertSameDecisions(t, original, parsed)
+
+		// Empty groups are written with an empty Disallow which is
+		// reported but otherwise the output should be clean
+		for _, diagnostic := range parsed.Diagnostics() {
+			if diagnostic.Code != CodeEmptyValue {
+				t.Errorf("Expected no diagnostics for %q, got %v", text, diagnostic)
+			}
+		}
+
+		if parsed.String() != text {
+			t.Errorf("Expected serialising to be stable, got %q then %q", text, parsed.String())
+		}
+	}
+}
+
+func TestRobotsTxt_writeCanonicalForm(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := `
+		Sitemap: http://www.example.com/sitemap.xml
+		User-agent: b
+		User-agent: a
+		Allow: /z
+		Disallow: /a # comment
+		Disallow: /a
+		Crawl-delay: 2
+
+		User-agent: *
+		Disallow: /private%20files/
+		Disallow: /*.pdf$
+		Disallow: /price%24
+	`
+
+	expected := "User-agent: *\n" +
+		"Disallow: /*.pdf$\n" +
+		"Disallow: /price%24\n" +
+		"Disallow: /private%20files/\n" +
+		"\n" +
+		"User-agent: a\n" +
+		"User-agent: b\n" +
+		"Disallow: /a\n" +
+		"Allow: /z\n" +
+		"Crawl-delay: 2\n" +
+		"\n" +
+		"Sitemap: http://www.example.com/sitemap.xml\n"
+
+	robots, _ := Parse(contents, url)
+
+	var sb strings.Builder
+	n, err := robots.WriteTo(&sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+
+	if n != int64(len(expected)) {
+		t.Errorf("Expected %d bytes written, got %d", len(expected), n)
+	}
+}
+
+func TestRobotsTxt_keepRuleOrderForLegacyPrecedence(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := `
+		User-agent: *
+		Disallow: /fish*.php
+		Allow: /fish*
+	`
+
+	expected := "User-agent: *\n" +
+		"Disallow: /fish*.php\n" +
+		"Allow: /fish*\n"
+
+	robots, _ := Parse(contents, url, WithPrecedence(LegacyOrder))
+	if robots.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, robots.String())
+	}
+
+	// The last of equally long plain rules wins so duplicates are kept
+	robots, _ = Parse("User-agent: *\nDisallow: /a\nAllow: /a\nDisallow: /a\n", url, WithPrecedence(LegacyOrder))
+	expected = "User-agent: *\n" +
+		"Disallow: /a\n" +
+		"Allow: /a\n" +
+		"Disallow: /a\n"
+
+	if robots.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, robots.String())
+	}
+
+	parsed, _ := Parse(robots.String(), url, WithPrecedence(LegacyOrder))
+	if allowed, _ := parsed.IsAllowed("bot", "http://www.example.com/a"); allowed {
+		t.Errorf("Expected /a to stay disallowed after a round trip")
+	}
+}
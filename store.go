@@ -0,0 +1,253 @@
+package robotstxt
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by a Store when it has no entry for a URL
+var ErrNotFound = errors.New("robotstxt: entry not found")
+
+// Entry is the raw response for a robots.txt file. Entries are kept
+// unparsed so that stored copies are always read with the current
+// version of the parser.
+type Entry struct {
+	// URL is the robots.txt URL that was requested
+	URL string `json:"url"`
+	// FinalURL is the URL the response came from after redirects
+	FinalURL string `json:"finalUrl"`
+	// FetchedAt is when the response was received
+	FetchedAt time.Time `json:"fetchedAt"`
+	// StatusCode is the HTTP status code or 0 if the server
+	// could not be reached
+	StatusCode int `json:"statusCode"`
+	// Header is the HTTP response header
+	Header http.Header `json:"header,omitempty"`
+	// Body is the response body for 2xx responses
+	Body []byte `json:"body,omitempty"`
+}
+
+// Parse returns the RobotsTxt for the entry following the same RFC 9309
+// status code rules as Fetch
+func (e *Entry) Parse(opts ...Option) (*RobotsTxt, error) {
+	info := &FetchInfo{
+		Status:     statusFor(e.StatusCode),
+		StatusCode: e.StatusCode,
+		URL:        e.FinalURL,
+		Header:     e.Header,
+		FetchedAt:  e.FetchedAt,
+	}
+
+	var contents string
+	switch info.Status {
+	case StatusParsed:
+		contents = string(e.Body)
+	case StatusUnreachable:
+		contents = "User-agent: *\nDisallow: /"
+	}
+
+	// Rules apply to the site that was requested, not the one
+	// that was redirected to
+	robots, err := Parse(contents, e.URL, append([]Option{WithMaxBytes(DefaultMaxBytes)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	robots.fetch = info
+
+	return robots, nil
+}
+
+// Store persists robots.txt entries keyed by their robots.txt URL.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the entry for robotsURL or ErrNotFound
+	Get(ctx context.Context, robotsURL string) (*Entry, error)
+	// Put adds or replaces the entry for robotsURL
+	Put(ctx context.Context, robotsURL string, entry *Entry) error
+	// Delete removes the entry for robotsURL if there is one
+	Delete(ctx context.Context, robotsURL string) error
+}
+
+// MemoryStore is a Store that keeps up to a fixed number of entries in
+// memory, evicting the least recently used entry when full
+type MemoryStore struct {
+	capacity int
+
+	mu       sync.Mutex
+	elements map[string]*list.Element
+	order    *list.List
+}
+
+type memoryStoreItem struct {
+	robotsURL string
+	entry     *Entry
+}
+
+// NewMemoryStore returns a MemoryStore that holds up to capacity entries.
+// A capacity of 0 or less means there is no limit.
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		elements: make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the entry for robotsURL or ErrNotFound
+func (s *MemoryStore) Get(ctx context.Context, robotsURL string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.elements[robotsURL]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	s.order.MoveToFront(element)
+
+	return element.Value.(*memoryStoreItem).entry, nil
+}
+
+// Put adds or replaces the entry for robotsURL
+func (s *MemoryStore) Put(ctx context.Context, robotsURL string, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.elements[robotsURL]; ok {
+		element.Value.(*memoryStoreItem).entry = entry
+		s.order.MoveToFront(element)
+		return nil
+	}
+
+	s.elements[robotsURL] = s.order.PushFront(&memoryStoreItem{
+		robotsURL: robotsURL,
+		entry:     entry,
+	})
+
+	if s.capacity > 0 && s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.elements, oldest.Value.(*memoryStoreItem).robotsURL)
+	}
+
+	return nil
+}
+
+// Delete removes the entry for robotsURL if there is one
+func (s *MemoryStore) Delete(ctx context.Context, robotsURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.elements[robotsURL]; ok {
+		s.order.Remove(element)
+		delete(s.elements, robotsURL)
+	}
+
+	return nil
+}
+
+// Len returns the number of entries in the store
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.order.Len()
+}
+
+// FileStore is a Store that keeps each entry as a JSON file in a directory
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a FileStore that keeps entries in dir,
+// creating it if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(robotsURL string) string {
+	hash := sha256.Sum256([]byte(robotsURL))
+	return filepath.Join(s.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+// Get returns the entry for robotsURL or ErrNotFound
+func (s *FileStore) Get(ctx context.Context, robotsURL string) (*Entry, error) {
+	data, err := os.ReadFile(s.path(robotsURL))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+
+	// Guard against hash collisions
+	if entry.URL != robotsURL {
+		return nil, ErrNotFound
+	}
+
+	return &entry, nil
+}
+
+// Put adds or replaces the entry for robotsURL
+func (s *FileStore) Put(ctx context.Context, robotsURL string, entry *Entry) error {
+	stored := *entry
+	stored.URL = robotsURL
+
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename so readers
+	// never see a partially written entry
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), s.path(robotsURL)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes the entry for robotsURL if there is one
+func (s *FileStore) Delete(ctx context.Context, robotsURL string) error {
+	err := os.Remove(s.path(robotsURL))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,188 @@
+package robotstxt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	robotsURL := "http://www.example.com/robots.txt"
+	entry := &Entry{
+		URL:        robotsURL,
+		FinalURL:   "https://www.example.com/robots.txt",
+		FetchedAt:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Cache-Control": {"max-age=60"}},
+		Body:       []byte("User-agent: *\nDisallow: /private"),
+	}
+
+	if _, err := store.Get(ctx, robotsURL); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := store.Put(ctx, robotsURL, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := store.Get(ctx, robotsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(stored, entry) {
+		t.Errorf("Expected %v, got %v", entry, stored)
+	}
+
+	if err := store.Delete(ctx, robotsURL); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Get(ctx, robotsURL); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+
+	if err := store.Delete(ctx, robotsURL); err != nil {
+		t.Errorf("Expected deleting a missing entry to succeed, got %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore(10))
+}
+
+func TestMemoryStore_evictLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(2)
+
+	store.Put(ctx, "a", &Entry{URL: "a"})
+	store.Put(ctx, "b", &Entry{URL: "b"})
+	store.Get(ctx, "a")
+	store.Put(ctx, "c", &Entry{URL: "c"})
+
+	if store.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", store.Len())
+	}
+
+	if _, err := store.Get(ctx, "b"); err != ErrNotFound {
+		t.Errorf("Expected b to be evicted")
+	}
+
+	for _, key := range []string{"a", "c"} {
+		if _, err := store.Get(ctx, key); err != nil {
+			t.Errorf("Expected %s to be stored", key)
+		}
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testStore(t, store)
+}
+
+func TestEntry_parseUsingStatusCodeRules(t *testing.T) {
+	body := []byte("User-agent: *\nDisallow: /private")
+	tests := []struct {
+		statusCode int
+		status     FetchStatus
+		allowed    bool
+	}{
+		{http.StatusOK, StatusParsed, false},
+		{http.StatusNotFound, StatusUnavailable, true},
+		{http.StatusServiceUnavailable, StatusUnreachable, false},
+		{0, StatusUnreachable, false},
+	}
+
+	for _, test := range tests {
+		entry := &Entry{
+			URL:        "http://www.example.com/robots.txt",
+			StatusCode: test.statusCode,
+			Body:       body,
+		}
+
+		robots, err := entry.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if robots.FetchInfo().Status != test.status {
+			t.Errorf("Expected %v for %d, got %v", test.status, test.statusCode, robots.FetchInfo().Status)
+		}
+
+		allowed, _ := robots.IsAllowed("bot", "http://www.example.com/private")
+		if allowed != test.allowed {
+			t.Errorf("Expected /private allowed to be %v for %d", test.allowed, test.statusCode)
+		}
+	}
+}
+
+func TestCache_useStoredEntriesUntilTheyExpire(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private")
+	}))
+	defer server.Close()
+
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := &Cache{Client: server.Client(), Store: store}
+	first.Get(context.Background(), server.URL)
+
+	// A new cache, like after a restart, should use the stored copy
+	second := &Cache{Client: server.Client(), Store: store}
+	allowed, err := second.IsAllowed(context.Background(), "bot", server.URL+"/private")
+	if err != nil {
+		t.Fatal(err)
+	} else if allowed {
+		t.Errorf("The path /private should be disallowed")
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected 1 request, got %d", n)
+	}
+
+	clock := &testClock{now: time.Now().Add(2 * time.Minute)}
+	third := &Cache{Client: server.Client(), Store: store, now: clock.Now}
+	third.Get(context.Background(), server.URL)
+
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("Expected an expired stored entry to be fetched again, got %d requests", n)
+	}
+}
+
+func TestCache_useExpiredStoredEntryWhenUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	robotsURL := server.URL + "/robots.txt"
+	store := NewMemoryStore(0)
+	store.Put(context.Background(), robotsURL, &Entry{
+		URL:        robotsURL,
+		FetchedAt:  time.Now().Add(-48 * time.Hour),
+		StatusCode: http.StatusOK,
+		Body:       []byte("User-agent: *\nDisallow: /private"),
+	})
+
+	cache := &Cache{Client: server.Client(), Store: store}
+	allowed, _ := cache.IsAllowed(context.Background(), "bot", server.URL+"/public")
+	if !allowed {
+		t.Errorf("The path /public should be allowed by the stored copy")
+	}
+}
@@ -0,0 +1,92 @@
+package robotstxt
+
+import (
+	"net/http"
+	"sync"
+)
+
+// DisallowedError is the error returned by Transport when a request
+// is blocked by the robots.txt file for its origin
+type DisallowedError struct {
+	URL       string
+	UserAgent string
+}
+
+func (e *DisallowedError) Error() string {
+	return "robotstxt: " + e.URL + " is disallowed for " + e.UserAgent
+}
+
+// Transport is an http.RoundTripper that checks each request against the
+// robots.txt file for its origin before sending it. Disallowed requests
+// return a *DisallowedError without making a network request.
+//
+// Requests for /robots.txt are never checked.
+type Transport struct {
+	// Base is used to make requests, including fetching robots.txt
+	// files. If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// UserAgent is the user agent robots.txt rules are checked for
+	UserAgent string
+
+	// Cache is used to fetch and cache robots.txt files. If nil, a
+	// Cache that fetches using Base with a timeout of
+	// DefaultFetchTimeout is created on first use.
+	Cache *Cache
+
+	once  sync.Once
+	cache *Cache
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path != "/robots.txt" {
+		allowed, err := t.getCache().IsAllowed(req.Context(), t.UserAgent, req.URL.String())
+		if err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+
+		if !allowed {
+			closeRequestBody(req)
+			return nil, &DisallowedError{
+				URL:       req.URL.String(),
+				UserAgent: t.UserAgent,
+			}
+		}
+	}
+
+	return t.base().RoundTrip(req)
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+
+	return http.DefaultTransport
+}
+
+func (t *Transport) getCache() *Cache {
+	t.once.Do(func() {
+		t.cache = t.Cache
+		if t.cache == nil {
+			// Use the base transport so fetching robots.txt
+			// files is never checked against itself
+			t.cache = &Cache{Client: &http.Client{
+				Transport: t.base(),
+				Timeout:   DefaultFetchTimeout,
+			}}
+		}
+	})
+
+	return t.cache
+}
+
+// closeRequestBody closes the body as RoundTrip must
+// even when returning an error
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
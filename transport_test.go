@@ -0,0 +1,130 @@
+package robotstxt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransport_blockDisallowedRequests(t *testing.T) {
+	var robotsRequests, pageRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			atomic.AddInt32(&robotsRequests, 1)
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n\nUser-agent: other\nDisallow: /")
+			return
+		}
+
+		atomic.AddInt32(&pageRequests, 1)
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &Transport{
+			Base:      server.Client().Transport,
+			UserAgent: "bot",
+		},
+	}
+
+	resp, err := client.Get(server.URL + "/public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	_, err = client.Get(server.URL + "/private/page")
+
+	var disallowed *DisallowedError
+	if !errors.As(err, &disallowed) {
+		t.Fatalf("Expected a DisallowedError, got %v", err)
+	}
+
+	if disallowed.URL != server.URL+"/private/page" || disallowed.UserAgent != "bot" {
+		t.Errorf("Unexpected DisallowedError %v", disallowed)
+	}
+
+	resp, err = client.Get(server.URL + "/robots.txt")
+	if err != nil {
+		t.Fatalf("Expected robots.txt to never be blocked, got %v", err)
+	}
+	resp.Body.Close()
+
+	if n := atomic.LoadInt32(&pageRequests); n != 1 {
+		t.Errorf("Expected 1 page request, got %d", n)
+	}
+
+	// One fetch by the cache and one by the client
+	if n := atomic.LoadInt32(&robotsRequests); n != 2 {
+		t.Errorf("Expected 2 robots.txt requests, got %d", n)
+	}
+}
+
+func TestTransport_useTheProvidedCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	cache := &Cache{Client: server.Client()}
+	client := &http.Client{
+		Transport: &Transport{
+			Base:      server.Client().Transport,
+			UserAgent: "bot",
+			Cache:     cache,
+		},
+	}
+
+	_, err := client.Get(server.URL + "/")
+
+	var disallowed *DisallowedError
+	if !errors.As(err, &disallowed) {
+		t.Errorf("Expected an unreachable robots.txt to disallow everything, got %v", err)
+	}
+}
+
+func TestTransport_disallowWhenRobotsTxtHangs(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			<-release
+			return
+		}
+
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := &http.Client{
+		Transport: &Transport{
+			Base:      server.Client().Transport,
+			UserAgent: "bot",
+			Cache:     &Cache{Client: server.Client(), FetchTimeout: 50 * time.Millisecond},
+		},
+		Timeout: time.Second,
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Get(server.URL + "/")
+
+		var disallowed *DisallowedError
+		if !errors.As(err, &disallowed) {
+			t.Errorf("Expected a robots.txt that hangs to disallow everything, got %v", err)
+		}
+	}
+
+	transport := &Transport{}
+	if timeout := transport.getCache().Client.Timeout; timeout != DefaultFetchTimeout {
+		t.Errorf("Expected the default cache to time out after %v, got %v", DefaultFetchTimeout, timeout)
+	}
+}
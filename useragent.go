@@ -0,0 +1,158 @@
+package robotstxt
+
+import "strings"
+
+// UserAgentMatching is the strategy used to find the group
+// that applies to a user agent
+type UserAgentMatching int
+
+const (
+	// MatchExact truncates the user agent at the first / and uses the
+	// group with exactly that name, ignoring case
+	MatchExact UserAgentMatching = iota
+
+	// MatchProductToken extracts the RFC 9309 product tokens from a full
+	// User-Agent header, such as
+	// "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+	// and uses the group for the first one that has a group. Product
+	// tokens in comments are tried first as that is where crawlers put
+	// their own name. Product tokens may only contain the characters
+	// a-z, A-Z, _ and -.
+	MatchProductToken
+)
+
+// WithUserAgentMatching sets the strategy used to find the group that
+// applies to a user agent. Defaults to MatchExact.
+func WithUserAgentMatching(matching UserAgentMatching) Option {
+	return func(o *options) {
+		o.userAgentMatching = matching
+	}
+}
+
+// WithFallbacks sets the user agents to try, in order, when there is no
+// group for userAgent before falling back to the * group. For example
+// WithFallbacks("Googlebot-News", "Googlebot") will use the Googlebot
+// group for Googlebot-News if there is no Googlebot-News group.
+func WithFallbacks(userAgent string, fallbacks ...string) Option {
+	return func(o *options) {
+		if o.fallbacks == nil {
+			o.fallbacks = make(map[string][]string)
+		}
+
+		var normalised []string
+		for _, fallback := range fallbacks {
+			normalised = append(normalised, normaliseUserAgent(fallback))
+		}
+
+		o.fallbacks[normaliseUserAgent(userAgent)] = normalised
+	}
+}
+
+// findGroup returns the group that applies to the user agent and
+// the name it was found under or nil if there is none
+func (r *RobotsTxt) findGroup(userAgent string) (string, *group) {
+	name, ok := findUserAgent(userAgent, r.matching, r.fallbacks, func(name string) bool {
+		_, ok := r.groups[name]
+		return ok
+	})
+
+	if !ok {
+		return "", nil
+	}
+
+	return name, r.groups[name]
+}
+
+// findUserAgent returns the name of the group that applies to the user
+// agent and false if there is none. hasGroup checks if there is a group
+// with a name.
+func findUserAgent(userAgent string, matching UserAgentMatching, fallbacks map[string][]string, hasGroup func(string) bool) (string, bool) {
+	var candidates []string
+	if matching == MatchProductToken {
+		candidates = productTokens(userAgent)
+	} else {
+		candidates = []string{normaliseUserAgent(userAgent)}
+	}
+
+	for _, candidate := range candidates {
+		if hasGroup(candidate) {
+			return candidate, true
+		}
+
+		for _, fallback := range fallbacks[candidate] {
+			if hasGroup(fallback) {
+				return fallback, true
+			}
+		}
+	}
+
+	if hasGroup("*") {
+		return "*", true
+	}
+
+	return "", false
+}
+
+func isProductTokenChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == '-'
+}
+
+// isProductToken checks if the string is a valid RFC 9309 product token
+func isProductToken(token string) bool {
+	if token == "" {
+		return false
+	}
+
+	for i := 0; i < len(token); i++ {
+		if !isProductTokenChar(token[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// productTokens returns the lower case product tokens in a User-Agent
+// header, those inside comments first. A product token is a run of
+// product token characters at the start of the header or after a space,
+// ( or ; that is followed by a / and version. If the whole header is a
+// valid product token it is returned on its own.
+func productTokens(header string) []string {
+	header = strings.TrimSpace(header)
+	if isProductToken(header) {
+		return []string{strings.ToLower(header)}
+	}
+
+	var inComments, outside []string
+	depth := 0
+
+	for i := 0; i < len(header); {
+		c := header[i]
+
+		switch {
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case isProductTokenChar(c) && (i == 0 || strings.IndexByte(" \t(;", header[i-1]) > -1):
+			start := i
+			for i < len(header) && isProductTokenChar(header[i]) {
+				i++
+			}
+
+			if i < len(header) && header[i] == '/' {
+				token := strings.ToLower(header[start:i])
+				if depth > 0 {
+					inComments = append(inComments, token)
+				} else {
+					outside = append(outside, token)
+				}
+			}
+			continue
+		}
+
+		i++
+	}
+
+	return append(inComments, outside...)
+}
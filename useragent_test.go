@@ -0,0 +1,109 @@
+package robotstxt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProductTokens(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected []string
+	}{
+		{"Googlebot", []string{"googlebot"}},
+		{"Googlebot/2.1", []string{"googlebot"}},
+		{
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			[]string{"googlebot", "mozilla"},
+		},
+		{
+			"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm) Chrome/116.0 Safari/537.36",
+			[]string{"bingbot", "mozilla", "applewebkit", "chrome", "safari"},
+		},
+		{"Sams_Bot-Crawler/1.0 (+https://example.com/bot)", []string{"sams_bot-crawler"}},
+		{"bot 2000", nil},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		actual := productTokens(test.header)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected %v for %q, got %v", test.expected, test.header, actual)
+		}
+	}
+}
+
+func TestRobotsTxt_matchProductTokens(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := `
+		User-agent: *
+		Disallow: /
+
+		User-agent: Googlebot
+		Disallow: /googlebot
+
+		User-agent: Mozilla
+		Disallow: /mozilla
+	`
+
+	robots, _ := Parse(contents, url, WithUserAgentMatching(MatchProductToken))
+
+	header := "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+	allowed, _ := robots.IsAllowed(header, "http://www.example.com/mozilla")
+	if !allowed {
+		t.Errorf("Expected the Googlebot group to be used for %s", header)
+	}
+
+	allowed, _ = robots.IsAllowed(header, "http://www.example.com/googlebot")
+	if allowed {
+		t.Errorf("Expected /googlebot to be disallowed for %s", header)
+	}
+
+	allowed, _ = robots.IsAllowed("Mozilla/5.0 (Windows NT 10.0)", "http://www.example.com/googlebot")
+	if !allowed {
+		t.Errorf("Expected the Mozilla group to be used when there is no crawler token")
+	}
+
+	allowed, _ = robots.IsAllowed("Unknown/1.0", "http://www.example.com/page")
+	if allowed {
+		t.Errorf("Expected the * group to be used for unknown user agents")
+	}
+
+	exact, _ := Parse(contents, url)
+	allowed, _ = exact.IsAllowed(header, "http://www.example.com/mozilla")
+	if allowed {
+		t.Errorf("Expected the default matching to use the Mozilla group")
+	}
+}
+
+func TestRobotsTxt_useFallbacksBeforeTheDefaultGroup(t *testing.T) {
+	url := "http://www.example.com/robots.txt"
+	contents := `
+		User-agent: *
+		Disallow: /
+		Crawl-delay: 10
+
+		User-agent: Googlebot
+		Disallow: /private
+		Crawl-delay: 1
+	`
+
+	robots, _ := Parse(contents, url,
+		WithUserAgentMatching(MatchProductToken),
+		WithFallbacks("Googlebot-News", "Googlebot-Extra", "Googlebot"))
+
+	allowed, _ := robots.IsAllowed("Googlebot-News", "http://www.example.com/news")
+	if !allowed {
+		t.Errorf("Expected Googlebot-News to fall back to the Googlebot group")
+	}
+
+	if robots.CrawlDelay("Googlebot-News/1.0") != time.Second {
+		t.Errorf("Expected Googlebot-News to use the Googlebot crawl delay")
+	}
+
+	allowed, _ = robots.IsAllowed("Googlebot-Image", "http://www.example.com/news")
+	if allowed {
+		t.Errorf("Expected Googlebot-Image to fall back to the * group")
+	}
+}